@@ -0,0 +1,234 @@
+package fuzzytime
+
+import (
+	"errors"
+	"time"
+)
+
+// Arithmetic.
+// The results keep the precision of the original, so adding a month to
+// "2010-05" gives "2010-06", and adding an hour to "T14:30" gives
+// "T15:30". Operations which need an unset field return an error.
+
+const msPerDay = 24 * 60 * 60 * 1000
+
+// AddDate returns the date with the given numbers of years, months and
+// days added (any of which can be negative).
+// Adding years needs the year to be set, adding months needs the year and
+// month, and adding days needs a full date. Like time.Time.AddDate,
+// overflowing days are normalised, so "2010-01-31" plus a month is
+// "2010-03-03".
+func (d *Date) AddDate(years, months, days int) (Date, error) {
+	out := *d
+	if years == 0 && months == 0 && days == 0 {
+		return out, nil
+	}
+	if !d.HasYear() {
+		return Date{}, errors.New("date has no year")
+	}
+	if (months != 0 || days != 0) && !d.HasMonth() {
+		return Date{}, errors.New("date has no month")
+	}
+	if days != 0 && !d.HasDay() {
+		return Date{}, errors.New("date has no day")
+	}
+
+	// the converted-from calendar date won't be right any more
+	out.orig = CalendarDate{}
+
+	day := 1
+	if d.HasDay() {
+		day = d.Day()
+	}
+	month := 1
+	if d.HasMonth() {
+		month = d.Month()
+	}
+	t := time.Date(d.Year()+years, time.Month(month+months), day+days, 0, 0, 0, 0, time.UTC)
+	out.SetYear(t.Year())
+	if d.HasMonth() {
+		out.SetMonth(int(t.Month()))
+	}
+	if d.HasDay() {
+		out.SetDay(t.Day())
+	}
+	if !out.HasYear() {
+		return Date{}, errors.New("year zero is not supported")
+	}
+	return out, nil
+}
+
+// Sub returns the number of days between two dates (d-other). Both dates
+// must be complete.
+func (d *Date) Sub(other *Date) (int, error) {
+	if !d.HasYear() || !d.HasMonth() || !d.HasDay() || !other.HasYear() || !other.HasMonth() || !other.HasDay() {
+		return 0, errors.New("dates must be complete")
+	}
+	return fixedFromGregorian(d.Year(), d.Month(), d.Day()) - fixedFromGregorian(other.Year(), other.Month(), other.Day()), nil
+}
+
+// precision returns the size of the smallest unit the time holds
+// (hour, minute, second or millisecond). Returns an error if the
+// hour is unset or the fields aren't contiguous (eg hour and second,
+// but no minute).
+func (t *Time) precision() (time.Duration, error) {
+	switch {
+	case !t.HasHour():
+		return 0, errors.New("time has no hour")
+	case !t.HasMinute():
+		if t.HasSecond() || t.HasFractional() {
+			break
+		}
+		return time.Hour, nil
+	case !t.HasSecond():
+		if t.HasFractional() {
+			break
+		}
+		return time.Minute, nil
+	case !t.HasFractional():
+		return time.Second, nil
+	default:
+		return time.Millisecond, nil
+	}
+	return 0, errors.New("time has missing fields")
+}
+
+// ms returns the time of day in milliseconds. Unset fields count as zero.
+func (t *Time) ms() int {
+	return ((t.Hour()*60+t.Minute())*60+t.Second())*1000 + t.Fractional()
+}
+
+// addDuration adds a duration to the time, returning the new time and
+// the number of days carried over.
+func (t *Time) addDuration(dur time.Duration) (Time, int, error) {
+	out := *t
+	if dur == 0 {
+		return out, 0, nil
+	}
+	unit, err := t.precision()
+	if err != nil {
+		return Time{}, 0, err
+	}
+	if dur%unit != 0 {
+		return Time{}, 0, errors.New("duration is finer than the time's precision")
+	}
+
+	ms := t.ms() + int(dur/time.Millisecond)
+	days := fdiv(ms, msPerDay)
+	ms = fmod(ms, msPerDay)
+
+	out.SetHour(ms / 3600000)
+	if t.HasMinute() {
+		out.SetMinute((ms / 60000) % 60)
+	}
+	if t.HasSecond() {
+		out.SetSecond((ms / 1000) % 60)
+	}
+	if t.HasFractional() {
+		out.SetFractional(ms % 1000)
+	}
+	return out, days, nil
+}
+
+// AddDuration returns the time with the duration added, wrapping around
+// midnight. The duration must be a whole number of the time's smallest
+// unit (eg whole hours, for "T14").
+func (t *Time) AddDuration(dur time.Duration) (Time, error) {
+	out, _, err := t.addDuration(dur)
+	return out, err
+}
+
+// Sub returns the duration t-other. Both times must have the same
+// precision, and either both or neither must have a timezone.
+func (t *Time) Sub(other *Time) (time.Duration, error) {
+	p1, err := t.precision()
+	if err != nil {
+		return 0, err
+	}
+	p2, err := other.precision()
+	if err != nil {
+		return 0, err
+	}
+	if p1 != p2 {
+		return 0, errors.New("times have different precision")
+	}
+	if t.HasTZOffset() != other.HasTZOffset() {
+		return 0, errors.New("only one time has a timezone")
+	}
+	ms := t.ms() - other.ms()
+	if t.HasTZOffset() {
+		ms -= (t.TZOffset() - other.TZOffset()) * 1000
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// AddDate returns the datetime with the given numbers of years, months
+// and days added. The time is unchanged. See Date.AddDate.
+func (dt *DateTime) AddDate(years, months, days int) (DateTime, error) {
+	d, err := dt.Date.AddDate(years, months, days)
+	if err != nil {
+		return DateTime{}, err
+	}
+	return DateTime{Date: d, Time: dt.Time}, nil
+}
+
+// AddDuration returns the datetime with the duration added. If the time
+// crosses midnight, the date is adjusted, which needs a full date.
+// Datetimes with no date at all just wrap around.
+func (dt *DateTime) AddDuration(dur time.Duration) (DateTime, error) {
+	t, days, err := dt.Time.addDuration(dur)
+	if err != nil {
+		return DateTime{}, err
+	}
+	out := DateTime{Date: dt.Date, Time: t}
+	if days != 0 && !dt.Date.Empty() {
+		if !dt.HasFullDate() {
+			return DateTime{}, errors.New("date must be complete to carry days")
+		}
+		if out.Date, err = dt.Date.AddDate(0, 0, days); err != nil {
+			return DateTime{}, err
+		}
+	}
+	return out, nil
+}
+
+// In returns the datetime converted to a different timezone offset (in
+// seconds from UTC), rolling the date over as needed. The datetime must
+// have a timezone. Converting a partial datetime works as long as the
+// needed fields are there: the date must be complete if the conversion
+// crosses midnight, and the time must be precise enough for the shift
+// (eg a time with just an hour can't be moved by 30 minutes). Times with
+// no date wrap around midnight.
+func (dt *DateTime) In(offset int) (DateTime, error) {
+	if !dt.HasTZOffset() {
+		return DateTime{}, errors.New("datetime has no timezone")
+	}
+	out, err := dt.AddDuration(time.Duration(offset-dt.TZOffset()) * time.Second)
+	if err != nil {
+		return DateTime{}, err
+	}
+	out.SetTZOffset(offset)
+	return out, nil
+}
+
+// UTC returns the datetime converted to UTC (see In)
+func (dt *DateTime) UTC() (DateTime, error) {
+	return dt.In(0)
+}
+
+// Sub returns the duration dt-other. Both must have complete dates, and
+// times of the same precision (see Time.Sub), or no times at all.
+func (dt *DateTime) Sub(other *DateTime) (time.Duration, error) {
+	days, err := dt.Date.Sub(&other.Date)
+	if err != nil {
+		return 0, err
+	}
+	if dt.Time.Empty() && other.Time.Empty() {
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	dur, err := dt.Time.Sub(&other.Time)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(days)*24*time.Hour + dur, nil
+}
@@ -0,0 +1,259 @@
+package fuzzytime
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Calendar identifies a non-Gregorian calendar system. Calendars are bit
+// flags, so a Context can enable several at once.
+type Calendar int
+
+const (
+	// HijriCalendar is the Islamic calendar (arithmetic/tabular variant).
+	// Months are numbered from Muharram (1) to Dhu al-Hijjah (12).
+	HijriCalendar Calendar = 1 << iota
+	// PersianCalendar is the Solar Hijri calendar used in Iran and
+	// Afghanistan. Months are numbered from Farvardin (1) to Esfand (12).
+	PersianCalendar
+	// HebrewCalendar is the Hebrew calendar. Months are numbered from
+	// Nisan (1), so Tishrei is 7, Adar (or Adar I) is 12 and Adar II is 13.
+	HebrewCalendar
+)
+
+// String returns the name of the calendar
+func (cal Calendar) String() string {
+	switch cal {
+	case 0:
+		return "Gregorian"
+	case HijriCalendar:
+		return "Hijri"
+	case PersianCalendar:
+		return "Persian"
+	case HebrewCalendar:
+		return "Hebrew"
+	}
+	return fmt.Sprintf("Calendar(%d)", int(cal))
+}
+
+// CalendarDate is a date in a non-Gregorian calendar.
+type CalendarDate struct {
+	Calendar         Calendar
+	Year, Month, Day int
+}
+
+// Gregorian converts the date to a (fully-specified) Gregorian Date.
+// An error is returned if the date is not valid in its calendar.
+func (cd CalendarDate) Gregorian() (Date, error) {
+	var fixed int
+	var err error
+	switch cd.Calendar {
+	case 0:
+		d := NewDate(cd.Year, cd.Month, cd.Day)
+		if !d.sane() {
+			return Date{}, errors.New("bad date")
+		}
+		return *d, nil
+	case HijriCalendar:
+		fixed, err = fixedFromHijri(cd.Year, cd.Month, cd.Day)
+	case PersianCalendar:
+		fixed, err = fixedFromPersian(cd.Year, cd.Month, cd.Day)
+	case HebrewCalendar:
+		fixed, err = fixedFromHebrew(cd.Year, cd.Month, cd.Day)
+	default:
+		return Date{}, errors.New("unknown calendar")
+	}
+	if err != nil {
+		return Date{}, err
+	}
+	return dateFromFixed(fixed), nil
+}
+
+// The conversions work via "fixed" day numbers, as used in
+// "Calendrical Calculations" (Reingold & Dershowitz), where day 1 is
+// January 1st, 1 AD (proleptic Gregorian).
+
+var fixedEpoch = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func dateFromFixed(fixed int) Date {
+	t := fixedEpoch.AddDate(0, 0, fixed-1)
+	return *NewDate(t.Year(), int(t.Month()), t.Day())
+}
+
+func fixedFromGregorian(year, month, day int) int {
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return int((t.Unix()-fixedEpoch.Unix())/(24*60*60)) + 1
+}
+
+// floor division
+func fdiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// floor modulus
+func fmod(a, b int) int {
+	return a - b*fdiv(a, b)
+}
+
+// Hijri (tabular, civil epoch)
+
+const hijriEpoch = 227015 // July 16th, 622 AD (Julian)
+
+func hijriLeapYear(year int) bool {
+	return fmod(14+11*year, 30) < 11
+}
+
+func hijriMonthLength(year, month int) int {
+	if month%2 == 1 || (month == 12 && hijriLeapYear(year)) {
+		return 30
+	}
+	return 29
+}
+
+func fixedFromHijri(year, month, day int) (int, error) {
+	if year < 1 || month < 1 || month > 12 || day < 1 || day > hijriMonthLength(year, month) {
+		return 0, errors.New("bad hijri date")
+	}
+	return day + 29*(month-1) + fdiv(6*month-1, 11) +
+		(year-1)*354 + fdiv(3+11*year, 30) + hijriEpoch - 1, nil
+}
+
+// Persian (Solar Hijri), using the 33-year-cycle algorithm of Borkowski,
+// which matches the astronomical calendar for years 1244-1802 SH.
+
+var persianBreaks = []int{-61, 9, 38, 199, 426, 686, 756, 818, 1111, 1181,
+	1210, 1635, 2060, 2097, 2192, 2262, 2324, 2394, 2456, 3178}
+
+// persianYear returns the Gregorian year in which the Persian year
+// starts, the day in March of Nowruz (1 Farvardin) and whether the
+// year is a leap year.
+func persianYear(year int) (gy int, march int, leap bool, err error) {
+	if year < persianBreaks[0] || year >= persianBreaks[len(persianBreaks)-1] {
+		return 0, 0, false, errors.New("persian year out of range")
+	}
+	gy = year + 621
+	leapJ := -14
+	jp := persianBreaks[0]
+	var jump int
+	for _, jm := range persianBreaks[1:] {
+		jump = jm - jp
+		if year < jm {
+			break
+		}
+		leapJ += fdiv(jump, 33)*8 + fdiv(fmod(jump, 33), 4)
+		jp = jm
+	}
+	n := year - jp
+	leapJ += fdiv(n, 33)*8 + fdiv(fmod(n, 33)+3, 4)
+	if fmod(jump, 33) == 4 && jump-n == 4 {
+		leapJ++
+	}
+	leapG := fdiv(gy, 4) - fdiv((fdiv(gy, 100)+1)*3, 4) - 150
+	march = 20 + leapJ - leapG
+	if jump-n < 6 {
+		n = n - jump + fdiv(jump+4, 33)*33
+	}
+	l := fmod(fmod(n+1, 33)-1, 4)
+	return gy, march, l == 0, nil
+}
+
+func fixedFromPersian(year, month, day int) (int, error) {
+	gy, march, leap, err := persianYear(year)
+	if err != nil {
+		return 0, err
+	}
+	maxDay := 31
+	if month > 6 {
+		maxDay = 30
+	}
+	if month == 12 && !leap {
+		maxDay = 29
+	}
+	if month < 1 || month > 12 || day < 1 || day > maxDay {
+		return 0, errors.New("bad persian date")
+	}
+	// first six months have 31 days, the rest 30 (or 29)
+	days := (month-1)*31 - fdiv(month, 7)*(month-7) + day - 1
+	return fixedFromGregorian(gy, 3, march) + days, nil
+}
+
+// Hebrew
+
+const (
+	hebrewEpoch  = -1373427 // 1 Tishrei, AM 1
+	hebrewTishri = 7
+)
+
+func hebrewLeapYear(year int) bool {
+	return fmod(7*year+1, 19) < 7
+}
+
+func hebrewLastMonth(year int) int {
+	if hebrewLeapYear(year) {
+		return 13
+	}
+	return 12
+}
+
+func hebrewElapsedDays(year int) int {
+	monthsElapsed := fdiv(235*year-234, 19)
+	partsElapsed := 12084 + 13753*monthsElapsed
+	days := 29*monthsElapsed + fdiv(partsElapsed, 25920)
+	if fmod(3*(days+1), 7) < 3 {
+		return days + 1
+	}
+	return days
+}
+
+func hebrewNewYear(year int) int {
+	ny0 := hebrewElapsedDays(year - 1)
+	ny1 := hebrewElapsedDays(year)
+	ny2 := hebrewElapsedDays(year + 1)
+	delay := 0
+	if ny2-ny1 == 356 {
+		delay = 2
+	} else if ny1-ny0 == 382 {
+		delay = 1
+	}
+	return hebrewEpoch + ny1 + delay
+}
+
+func hebrewMonthLength(year, month int) int {
+	yearLen := hebrewNewYear(year+1) - hebrewNewYear(year)
+	switch {
+	case month == 2 || month == 4 || month == 6 || month == 10 || month == 13:
+		return 29
+	case month == 12 && !hebrewLeapYear(year):
+		return 29
+	case month == 8 && yearLen%10 != 5: // short Marheshvan
+		return 29
+	case month == 9 && yearLen%10 == 3: // short Kislev
+		return 29
+	}
+	return 30
+}
+
+func fixedFromHebrew(year, month, day int) (int, error) {
+	if year < 1 || month < 1 || month > hebrewLastMonth(year) || day < 1 || day > hebrewMonthLength(year, month) {
+		return 0, errors.New("bad hebrew date")
+	}
+	fixed := hebrewNewYear(year) + day - 1
+	if month < hebrewTishri {
+		for m := hebrewTishri; m <= hebrewLastMonth(year); m++ {
+			fixed += hebrewMonthLength(year, m)
+		}
+		for m := 1; m < month; m++ {
+			fixed += hebrewMonthLength(year, m)
+		}
+	} else {
+		for m := hebrewTishri; m < month; m++ {
+			fixed += hebrewMonthLength(year, m)
+		}
+	}
+	return fixed, nil
+}
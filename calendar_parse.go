@@ -0,0 +1,211 @@
+package fuzzytime
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// calendarCracker is a regexp for dates in a particular non-Gregorian
+// calendar
+type calendarCracker struct {
+	cal    Calendar
+	pat    *regexp.Regexp
+	months map[string]int // keyed by calendarKey()
+}
+
+// calendarCrackers are built at init time, from calendarMonthLookup
+var calendarCrackers []calendarCracker
+
+// hebrewNumPat matches a number written in Hebrew letters. To avoid
+// picking up ordinary words, a geresh or gershayim is required
+// (eg "י׳", "תשע״ד", "ה'תשע\"ד")
+var hebrewNumPat = `[א-ת]{0,4}['"׳״](?:[א-ת]{1,4}['"׳״])?[א-ת]{0,2}`
+
+// markers which sometimes follow the year
+var calendarYearMarkers = map[Calendar]string{
+	HijriCalendar:   `(?:[\s\p{Z}]*(?:هـ|a\.?h\.?))?`,
+	PersianCalendar: `(?:[\s\p{Z}]*(?:هـ?[.\s]?ش|s\.?h\.?))?`,
+	HebrewCalendar:  ``,
+}
+
+func init() {
+	for _, cal := range []Calendar{HijriCalendar, PersianCalendar, HebrewCalendar} {
+		months := map[string]int{}
+		names := []string{}
+		for name, month := range calendarMonthLookup[cal] {
+			months[calendarKey(name)] = month
+			names = append(names, name)
+		}
+		// longest first, so eg "adar ii" is tried before "adar"
+		sort.Slice(names, func(i, j int) bool {
+			if len(names[i]) != len(names[j]) {
+				return len(names[i]) > len(names[j])
+			}
+			return names[i] < names[j]
+		})
+		pats := make([]string, len(names))
+		for i, name := range names {
+			pats[i] = calendarMonthPat(name)
+		}
+		monthPat := `(?P<month>` + strings.Join(pats, "|") + `)`
+		dayPat := `(?P<day>\d{1,2})`
+		yearPat := `(?P<year>\d{3,4})`
+		prefix := ``
+		if cal == HebrewCalendar {
+			dayPat = `(?P<day>\d{1,2}|` + hebrewNumPat + `)`
+			yearPat = `(?P<year>\d{4}|` + hebrewNumPat + `)`
+			prefix = `(?:ב-?)?` // "in"
+		}
+		marker := calendarYearMarkers[cal]
+
+		for _, pat := range []string{
+			// "10 جمادى الآخرة 1435", "21 فروردین 1393", "י׳ בניסן תשע״ד"
+			`(?i)` + dayPat + `[\s\p{Z}]+` + prefix + monthPat + `[.,\s\p{Z}]+` + yearPat + marker,
+			// "Ramadan 10, 1435"
+			`(?i)` + monthPat + `[\s\p{Z}]+(?P<day>\d{1,2})(?:st|nd|rd|th)?[.,\s\p{Z}]+(?P<year>\d{3,4})` + marker,
+		} {
+			calendarCrackers = append(calendarCrackers, calendarCracker{
+				cal:    cal,
+				pat:    regexp.MustCompile(pat),
+				months: months,
+			})
+		}
+	}
+}
+
+// calendarKey normalises a month name for lookup - case, spacing,
+// punctuation and vowel marks are discarded, and variant forms of some
+// Arabic/Persian letters are folded together.
+func calendarKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.Is(unicode.Mn, r) ||
+			r == '‌' || r == 'ـ' || r == '`' {
+			return -1
+		}
+		if folded, ok := letterVariants[r]; ok {
+			return folded
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// letterVariants maps interchangeable Arabic-script letters to a single form
+var letterVariants = map[rune]rune{
+	'أ': 'ا', 'إ': 'ا', 'آ': 'ا', 'ٱ': 'ا',
+	'ي': 'ی', 'ى': 'ی',
+	'ك': 'ک',
+	'ة': 'ه',
+}
+
+// calendarMonthPat builds a regexp fragment to match a month name, with
+// the same leniency as calendarKey.
+func calendarMonthPat(name string) string {
+	// collect the characters which fold together
+	classes := map[rune][]rune{}
+	for from, to := range letterVariants {
+		classes[to] = append(classes[to], from)
+	}
+	var out bytes.Buffer
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			out.WriteString(`[\s\p{Z}'’ʼ׳"״-]*`)
+		case unicode.Is(unicode.Mn, r):
+			// vowel marks are optional
+		default:
+			to, ok := letterVariants[r]
+			if !ok {
+				to = r
+			}
+			if variants, ok := classes[to]; ok {
+				out.WriteString("[" + string(to) + string(variants) + "]")
+			} else {
+				out.WriteString(regexp.QuoteMeta(string(r)))
+			}
+			out.WriteString(`\p{Mn}*`)
+		}
+	}
+	return out.String()
+}
+
+// calendarNumber parses a day or year, in digits or Hebrew numerals
+func calendarNumber(s string) (int, bool) {
+	n, err := strconv.Atoi(s)
+	if err == nil {
+		return n, true
+	}
+	// Hebrew numerals. A letter followed by a geresh and more letters
+	// gives the thousands (eg "ה'תשע״ד" is 5774)
+	runes := []rune(s)
+	thousands := 0
+	if len(runes) > 2 && (runes[1] == '\'' || runes[1] == '׳') {
+		thousands = hebrewNumerals[runes[0]] * 1000
+		runes = runes[2:]
+	}
+	for _, r := range runes {
+		n += hebrewNumerals[r]
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return thousands + n, true
+}
+
+// extractCalendarDate tries to parse a date in one of the non-Gregorian
+// calendars enabled in ctx, returning it converted to Gregorian.
+func (ctx *Context) extractCalendarDate(s string) (Date, Span, Fields, bool) {
+	for _, cc := range calendarCrackers {
+		if ctx.Calendars&cc.cal == 0 {
+			continue
+		}
+		names := cc.pat.SubexpNames()
+		matchSpans := cc.pat.FindStringSubmatchIndex(s)
+		if matchSpans == nil {
+			continue
+		}
+
+		cd := CalendarDate{Calendar: cc.cal}
+		var fields Fields
+		ok := true
+		for i, name := range names {
+			start, end := matchSpans[i*2], matchSpans[(i*2)+1]
+			if start < 0 {
+				continue
+			}
+			sub := s[start:end]
+			switch name {
+			case "day":
+				fields.Day.Span = Span{start, end}
+				cd.Day, ok = calendarNumber(sub)
+			case "month":
+				fields.Month.Span = Span{start, end}
+				cd.Month, ok = cc.months[calendarKey(sub)]
+			case "year":
+				fields.Year.Span = Span{start, end}
+				cd.Year, ok = calendarNumber(sub)
+				if ok && cc.cal == HebrewCalendar && cd.Year < 1000 {
+					// thousands usually omitted
+					cd.Year += 5000
+				}
+			}
+			if !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		fd, err := cd.Gregorian()
+		if err != nil {
+			continue
+		}
+		fd.orig = cd
+		return fd, Span{matchSpans[0], matchSpans[1]}, fields, true
+	}
+	return Date{}, Span{}, Fields{}, false
+}
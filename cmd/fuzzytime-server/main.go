@@ -0,0 +1,278 @@
+// Command fuzzytime-server exposes fuzzytime extraction as a small
+// HTTP/JSON service, for programs not written in Go.
+//
+// Usage:
+//
+//	fuzzytime-server [-addr localhost:8080]
+//
+// POST a JSON request to /extract:
+//
+//	{
+//	  "text": "Posted 10/11/12 at 15:00 GMT",
+//	  "resolver": "dmy",
+//	  "tz": "GB,US",
+//	  "all": false,
+//	  "fuzzy": false
+//	}
+//
+// and get back:
+//
+//	{
+//	  "input": "Posted 10/11/12 at 15:00 GMT",
+//	  "matches": [{
+//	    "iso": "2012-11-10T15:00Z",
+//	    "fields": {"year": 2012, "month": 11, "day": 10, "hour": 15, "minute": 0, "tz_offset": 0},
+//	    "spans": [[7, 15], [19, 28]],
+//	    "rune_spans": [[7, 15], [19, 28]],
+//	    "utf16_spans": [[7, 15], [19, 28]],
+//	    "text": ["10/11/12", "15:00 GMT"]
+//	  }]
+//	}
+//
+// "spans" are byte offsets into the UTF-8 input. "rune_spans" count
+// characters (code points), and "utf16_spans" count UTF-16 code units, as
+// used by JavaScript strings.
+//
+// Use "texts" (a list of strings) instead of "text" to extract from a
+// batch in one request. The response is then {"results": [...]}, with
+// one result per input. Extraction errors (eg an ambiguous date with
+// resolver "none") are reported in the "error" field of each result.
+// Malformed requests get a 400 status and {"error": "..."}. So do
+// texts longer than 64KB, to keep the time spent on each request
+// bounded.
+//
+// GET /extract?text=...&resolver=...&tz=... works too, for single strings.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bcampbell/fuzzytime"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	flag.Parse()
+
+	http.Handle("/extract", http.HandlerFunc(handleExtract))
+	srv := &http.Server{
+		Addr:         *addr,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	log.Printf("listening on %s\n", *addr)
+	if err := srv.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "fuzzytime-server: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+const (
+	// maxRequestSize limits the size of request bodies
+	maxRequestSize = 1024 * 1024
+	// maxTextSize limits the size of each text to extract from
+	maxTextSize = 64 * 1024
+)
+
+// request is an extraction request. Exactly one of Text or Texts
+// should be set.
+type request struct {
+	Text  *string  `json:"text"`
+	Texts []string `json:"texts"`
+	// Resolver is the DateResolver to use: "dmy", "mdy" or "none" (the default)
+	Resolver string `json:"resolver"`
+	// TZ lists preferred locales for ambiguous timezones, eg "GB,US"
+	TZ    string `json:"tz"`
+	All   bool   `json:"all"`
+	Fuzzy bool   `json:"fuzzy"`
+}
+
+// fields holds the parts of a DateTime which are set
+type fields struct {
+	Year       *int `json:"year,omitempty"`
+	Month      *int `json:"month,omitempty"`
+	Day        *int `json:"day,omitempty"`
+	Hour       *int `json:"hour,omitempty"`
+	Minute     *int `json:"minute,omitempty"`
+	Second     *int `json:"second,omitempty"`
+	Fractional *int `json:"fractional,omitempty"`
+	TZOffset   *int `json:"tz_offset,omitempty"`
+}
+
+// match is a datetime found in the input
+type match struct {
+	ISO        string   `json:"iso"`
+	Fields     fields   `json:"fields"`
+	Spans      [][2]int `json:"spans"`
+	RuneSpans  [][2]int `json:"rune_spans"`
+	UTF16Spans [][2]int `json:"utf16_spans"`
+	Text       []string `json:"text"`
+}
+
+// result holds the matches found in one input
+type result struct {
+	Input   string  `json:"input"`
+	Matches []match `json:"matches"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type batchResult struct {
+	Results []result `json:"results"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// handleExtract handles requests to /extract
+func handleExtract(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case "GET":
+		q := r.URL.Query()
+		if _, ok := q["text"]; !ok {
+			writeError(w, http.StatusBadRequest, errors.New("missing text"))
+			return
+		}
+		text := q.Get("text")
+		req.Text = &text
+		req.Resolver = q.Get("resolver")
+		req.TZ = q.Get("tz")
+		req.All, _ = strconv.ParseBool(q.Get("all"))
+		req.Fuzzy, _ = strconv.ParseBool(q.Get("fuzzy"))
+	case "POST":
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("bad request: %s", err))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	if (req.Text == nil) == (req.Texts == nil) {
+		writeError(w, http.StatusBadRequest, errors.New("need one of text or texts"))
+		return
+	}
+	texts := req.Texts
+	if req.Text != nil {
+		texts = []string{*req.Text}
+	}
+	for _, s := range texts {
+		if len(s) > maxTextSize {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("text too long (limit is %d bytes)", maxTextSize))
+			return
+		}
+	}
+	ctx, err := buildContext(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Text != nil {
+		writeJSON(w, http.StatusOK, extract(ctx, *req.Text, req.All))
+		return
+	}
+	out := batchResult{Results: make([]result, len(req.Texts))}
+	for i, s := range req.Texts {
+		out.Results[i] = extract(ctx, s, req.All)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// buildContext sets up a Context from the request settings
+func buildContext(req *request) (*fuzzytime.Context, error) {
+	ctx := fuzzytime.DefaultContext
+	switch req.Resolver {
+	case "dmy":
+		ctx.DateResolver = fuzzytime.DMYResolver
+	case "mdy":
+		ctx.DateResolver = fuzzytime.MDYResolver
+	case "", "none":
+	default:
+		return nil, fmt.Errorf("unknown resolver %q", req.Resolver)
+	}
+	if req.TZ != "" {
+		ctx.TZResolver = fuzzytime.DefaultTZResolver(req.TZ)
+	}
+	ctx.FuzzyNames = req.Fuzzy
+	return &ctx, nil
+}
+
+// extract runs the extraction on a single input
+func extract(ctx *fuzzytime.Context, s string, all bool) result {
+	res := result{Input: s, Matches: []match{}}
+	var matches []fuzzytime.Match
+	var err error
+	if all {
+		matches, err = ctx.ExtractAll(s)
+	} else {
+		var dt fuzzytime.DateTime
+		var spans []fuzzytime.Span
+		dt, spans, err = ctx.Extract(s)
+		if err == nil && !dt.Empty() {
+			matches = []fuzzytime.Match{{DateTime: dt, Spans: spans}}
+		}
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	for i := range matches {
+		m := &matches[i]
+		out := match{ISO: m.DateTime.ISOFormat(), Fields: dateTimeFields(&m.DateTime)}
+		for _, span := range m.Spans {
+			out.Spans = append(out.Spans, [2]int{span.Begin, span.End})
+			r := span.Runes(s)
+			out.RuneSpans = append(out.RuneSpans, [2]int{r.Begin, r.End})
+			u := span.UTF16(s)
+			out.UTF16Spans = append(out.UTF16Spans, [2]int{u.Begin, u.End})
+			out.Text = append(out.Text, s[span.Begin:span.End])
+		}
+		res.Matches = append(res.Matches, out)
+	}
+	return res
+}
+
+// dateTimeFields returns the fields which are set in dt
+func dateTimeFields(dt *fuzzytime.DateTime) fields {
+	var f fields
+	set := func(has bool, v int) *int {
+		if !has {
+			return nil
+		}
+		return &v
+	}
+	f.Year = set(dt.HasYear(), dt.Year())
+	f.Month = set(dt.HasMonth(), dt.Month())
+	f.Day = set(dt.HasDay(), dt.Day())
+	f.Hour = set(dt.HasHour(), dt.Hour())
+	f.Minute = set(dt.HasMinute(), dt.Minute())
+	f.Second = set(dt.HasSecond(), dt.Second())
+	f.Fractional = set(dt.HasFractional(), dt.Fractional())
+	f.TZOffset = set(dt.HasTZOffset(), dt.TZOffset())
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(v); err != nil {
+		log.Printf("write failed: %s\n", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, srv *httptest.Server, body string) (int, []byte) {
+	resp, err := http.Post(srv.URL+"/extract", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, raw
+}
+
+func TestExtractSingle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(handleExtract))
+	defer srv.Close()
+
+	status, raw := post(t, srv, `{"text": "Posted 10/11/12 at 15:00 GMT", "resolver": "dmy"}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", status, raw)
+	}
+	var res result
+	if err := json.Unmarshal(raw, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != "" || len(res.Matches) != 1 {
+		t.Fatalf("unexpected result %s", raw)
+	}
+	m := res.Matches[0]
+	if m.ISO != "2012-11-10T15:00Z" {
+		t.Errorf("expected 2012-11-10T15:00Z, got %s", m.ISO)
+	}
+	if *m.Fields.Year != 2012 || *m.Fields.Month != 11 || *m.Fields.Day != 10 || *m.Fields.TZOffset != 0 || m.Fields.Second != nil {
+		t.Errorf("unexpected fields %s", raw)
+	}
+	expectText := []string{"10/11/12", "15:00 GMT"}
+	if !reflect.DeepEqual(m.Text, expectText) {
+		t.Errorf("expected text %q, got %q", expectText, m.Text)
+	}
+	for i, span := range m.Spans {
+		if res.Input[span[0]:span[1]] != m.Text[i] {
+			t.Errorf("span %v doesn't match text %q", span, m.Text[i])
+		}
+	}
+}
+
+func TestExtractBatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(handleExtract))
+	defer srv.Close()
+
+	// no resolver, so the middle one is ambiguous
+	status, raw := post(t, srv, `{"texts": ["1 March 2010", "10/11/12", "nothing", "2010-03-01 and 2010-03-02"], "all": true}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", status, raw)
+	}
+	var res batchResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results) != 4 {
+		t.Fatalf("expected 4 results, got %s", raw)
+	}
+	isos := func(r result) []string {
+		out := []string{}
+		for _, m := range r.Matches {
+			out = append(out, m.ISO)
+		}
+		return out
+	}
+	expect := [][]string{{"2010-03-01"}, {}, {}, {"2010-03-01", "2010-03-02"}}
+	for i, r := range res.Results {
+		if got := isos(r); !reflect.DeepEqual(got, expect[i]) {
+			t.Errorf("%d: expected %q, got %q", i, expect[i], got)
+		}
+	}
+	if res.Results[1].Error == "" {
+		t.Errorf("expected an error for ambiguous date")
+	}
+}
+
+func TestExtractUnicodeSpans(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(handleExtract))
+	defer srv.Close()
+
+	// "Дата:" is 9 bytes, and the emoji is 4 bytes (2 UTF-16 units)
+	status, raw := post(t, srv, `{"text": "\ud83d\udcc5 Дата: 10 March 2010"}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", status, raw)
+	}
+	var res result
+	if err := json.Unmarshal(raw, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 1 {
+		t.Fatalf("unexpected result %s", raw)
+	}
+	m := res.Matches[0]
+	if m.Spans[0] != [2]int{15, 28} || m.RuneSpans[0] != [2]int{8, 21} || m.UTF16Spans[0] != [2]int{9, 22} {
+		t.Errorf("unexpected spans %s", raw)
+	}
+}
+
+func TestExtractGET(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(handleExtract))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/extract?resolver=mdy&text=" + url.QueryEscape("10/11/12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var res result
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Matches) != 1 || res.Matches[0].ISO != "2012-10-11" {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestExtractBadRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(handleExtract))
+	defer srv.Close()
+
+	for _, body := range []string{
+		`{`,
+		`{}`,
+		`{"text": "x", "texts": ["y"]}`,
+		`{"text": "x", "resolver": "ydm"}`,
+		`{"text": "` + strings.Repeat("x", maxTextSize+1) + `"}`,
+		`{"texts": ["x", "` + strings.Repeat("x", maxTextSize+1) + `"]}`,
+	} {
+		status, raw := post(t, srv, body)
+		if status != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, status)
+		}
+		var res errorResponse
+		if json.Unmarshal(raw, &res) != nil || res.Error == "" {
+			t.Errorf("%s: expected error message, got %s", body, raw)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Command fuzzytime extracts dates and times from text.
+//
+// It reads the files named on the command line (or stdin if none, or
+// "-"), and prints the datetime found on each line (or in each whole
+// file, with -whole). Lines are limited to 16MB; use -whole for longer
+// ones.
+//
+// Usage:
+//
+//	fuzzytime [flags] [file ...]
+//
+// Output formats (-format):
+//
+//	iso       the ISO8601 datetime, one line per input (tab-separated with -all)
+//	json      a JSON object per input, with spans (as byte, rune and UTF-16
+//	          offsets) and any error
+//	annotate  the input text, with matches marked as {{text|iso}}
+//	human     relative to the reference time (-ref), eg "3 days ago"
+//
+// For example:
+//
+//	$ echo "Published on March 10th, 1999 by Brian Credability" | fuzzytime -format annotate
+//	Published on {{March 10th, 1999|1999-03-10}} by Brian Credability
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bcampbell/fuzzytime"
+)
+
+// options holds the command-line settings
+type options struct {
+	ctx    fuzzytime.Context
+	ref    time.Time
+	strict bool
+	all    bool
+	whole  bool
+	format string
+}
+
+func main() {
+	var opts options
+	resolver := flag.String("resolver", "none", "how to resolve ambiguous dates like 10/11/12: dmy, mdy or none")
+	tz := flag.String("tz", "", "preferred locales for ambiguous timezones, eg \"GB,US\"")
+	ref := flag.String("ref", "", "reference time for -format human (RFC3339, default now)")
+	flag.BoolVar(&opts.strict, "strict", false, "stop on extraction errors (eg ambiguous dates), instead of reporting and carrying on")
+	flag.BoolVar(&opts.ctx.FuzzyNames, "fuzzy", false, "allow misspelt month/day names and OCR errors")
+	flag.BoolVar(&opts.all, "all", false, "output all matches, not just the first")
+	flag.BoolVar(&opts.whole, "whole", false, "treat each file as a single input, rather than line by line")
+	flag.StringVar(&opts.format, "format", "iso", "output format: iso, json, annotate or human")
+	flag.Parse()
+
+	var err error
+	opts.ctx.DateResolver, err = dateResolver(*resolver)
+	if err != nil {
+		fatal(err)
+	}
+	opts.ctx.TZResolver = fuzzytime.DefaultTZResolver(*tz)
+	opts.ref = time.Now()
+	if *ref != "" {
+		opts.ref, err = time.Parse(time.RFC3339, *ref)
+		if err != nil {
+			fatal(fmt.Errorf("bad -ref: %s", err))
+		}
+	}
+	switch opts.format {
+	case "iso", "json", "annotate", "human":
+	default:
+		fatal(fmt.Errorf("unknown format %q", opts.format))
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, filename := range files {
+		if err := runFile(&opts, filename, out); err != nil {
+			out.Flush()
+			fatal(err)
+		}
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "fuzzytime: %s\n", err)
+	os.Exit(1)
+}
+
+// dateResolver returns the DateResolver for a -resolver name
+func dateResolver(name string) (func(a, b, c int) (fuzzytime.Date, error), error) {
+	switch name {
+	case "dmy":
+		return fuzzytime.DMYResolver, nil
+	case "mdy":
+		return fuzzytime.MDYResolver, nil
+	case "none":
+		return fuzzytime.DefaultContext.DateResolver, nil
+	}
+	return nil, fmt.Errorf("unknown resolver %q", name)
+}
+
+// maxLineLen is the longest line accepted when reading line by line
+var maxLineLen = 16 * 1024 * 1024
+
+// runFile processes one named input ("-" for stdin)
+func runFile(opts *options, filename string, out io.Writer) error {
+	if filename == "-" {
+		return run(opts, os.Stdin, filename, out)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return run(opts, f, filename, out)
+}
+
+// run processes one input
+func run(opts *options, in io.Reader, filename string, out io.Writer) error {
+	if opts.whole {
+		data, err := ioutil.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		return process(opts, string(data), filename, 0, out)
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, maxLineLen)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if err := process(opts, scanner.Text(), filename, line, out); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err == bufio.ErrTooLong {
+		return fmt.Errorf("%s:%d: line longer than %d bytes (try -whole)", filename, line+1, maxLineLen)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// jsonMatch is the JSON output for a single match
+type jsonMatch struct {
+	DateTime fuzzytime.DateTime `json:"datetime"`
+	ISO      string             `json:"iso"`
+	Text     []string           `json:"text"`
+	// byte, rune and UTF-16 offsets
+	Spans      [][2]int `json:"spans"`
+	RuneSpans  [][2]int `json:"rune_spans"`
+	UTF16Spans [][2]int `json:"utf16_spans"`
+}
+
+// jsonResult is the JSON output for an input
+type jsonResult struct {
+	File    string      `json:"file"`
+	Line    int         `json:"line,omitempty"`
+	Matches []jsonMatch `json:"matches"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// process extracts from one line (or file) and writes the output
+func process(opts *options, s string, filename string, line int, out io.Writer) error {
+	matches, err := extract(opts, s)
+	if err != nil {
+		if opts.strict {
+			if line > 0 {
+				return fmt.Errorf("%s:%d: %s", filename, line, err)
+			}
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+		if opts.format != "json" {
+			if line > 0 {
+				fmt.Fprintf(os.Stderr, "%s:%d: %s\n", filename, line, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", filename, err)
+			}
+		}
+	}
+
+	switch opts.format {
+	case "iso", "human":
+		parts := make([]string, len(matches))
+		for i := range matches {
+			if opts.format == "human" {
+				parts[i] = matches[i].DateTime.Humanize(opts.ref)
+			} else {
+				parts[i] = matches[i].DateTime.ISOFormat()
+			}
+		}
+		_, e := fmt.Fprintln(out, strings.Join(parts, "\t"))
+		return e
+	case "json":
+		res := jsonResult{File: filename, Line: line, Matches: []jsonMatch{}}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		for _, m := range matches {
+			jm := jsonMatch{DateTime: m.DateTime, ISO: m.DateTime.ISOFormat()}
+			for _, span := range m.Spans {
+				jm.Text = append(jm.Text, s[span.Begin:span.End])
+				jm.Spans = append(jm.Spans, [2]int{span.Begin, span.End})
+				r := span.Runes(s)
+				jm.RuneSpans = append(jm.RuneSpans, [2]int{r.Begin, r.End})
+				u := span.UTF16(s)
+				jm.UTF16Spans = append(jm.UTF16Spans, [2]int{u.Begin, u.End})
+			}
+			res.Matches = append(res.Matches, jm)
+		}
+		data, e := json.Marshal(res)
+		if e != nil {
+			return e
+		}
+		_, e = fmt.Fprintf(out, "%s\n", data)
+		return e
+	case "annotate":
+		_, e := fmt.Fprintln(out, annotate(s, matches))
+		return e
+	}
+	return errors.New("unknown format")
+}
+
+// extract finds the first match, or all of them with -all
+func extract(opts *options, s string) ([]fuzzytime.Match, error) {
+	if opts.all {
+		return opts.ctx.ExtractAll(s)
+	}
+	dt, spans, err := opts.ctx.Extract(s)
+	if err != nil || dt.Empty() {
+		return nil, err
+	}
+	return []fuzzytime.Match{{DateTime: dt, Spans: spans}}, nil
+}
+
+// annotate marks the matched text as {{text|iso}}. A match made up of
+// several spans (eg a date and a time) is marked as a single block from
+// the start of the first span to the end of the last.
+func annotate(s string, matches []fuzzytime.Match) string {
+	out := ""
+	pos := 0
+	for i := range matches {
+		span := matches[i].Span()
+		if span.Begin < pos {
+			continue // overlaps the previous match
+		}
+		out += s[pos:span.Begin] + "{{" + s[span.Begin:span.End] + "|" + matches[i].DateTime.ISOFormat() + "}}"
+		pos = span.End
+	}
+	return out + s[pos:]
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bcampbell/fuzzytime"
+)
+
+func TestRun(t *testing.T) {
+	input := "From 2010-03-10 to 2010-03-11\nnothing here\n"
+	ref := time.Date(2010, 3, 13, 12, 0, 0, 0, time.UTC)
+
+	testData := []struct {
+		format string
+		all    bool
+		expect string
+	}{
+		{"iso", false, "2010-03-10\n\n"},
+		{"iso", true, "2010-03-10\t2010-03-11\n\n"},
+		{"annotate", true, "From {{2010-03-10|2010-03-10}} to {{2010-03-11|2010-03-11}}\nnothing here\n"},
+		{"human", false, "3 days ago\n\n"},
+		{"json", false, `{"file":"-","line":1,"matches":[{"datetime":"2010-03-10","iso":"2010-03-10","text":["2010-03-10"],"spans":[[5,15]],"rune_spans":[[5,15]],"utf16_spans":[[5,15]]}]}` + "\n" +
+			`{"file":"-","line":2,"matches":[]}` + "\n"},
+	}
+
+	for _, dat := range testData {
+		opts := options{ctx: fuzzytime.DefaultContext, ref: ref, format: dat.format, all: dat.all}
+		var out bytes.Buffer
+		if err := run(&opts, strings.NewReader(input), "-", &out); err != nil {
+			t.Errorf("%s: unexpected error: %s", dat.format, err)
+			continue
+		}
+		if out.String() != dat.expect {
+			t.Errorf("%s (all=%v): expected %q, got %q", dat.format, dat.all, dat.expect, out.String())
+		}
+	}
+}
+
+func TestRunStrict(t *testing.T) {
+	opts := options{ctx: fuzzytime.DefaultContext, format: "iso", strict: true}
+	var out bytes.Buffer
+	err := run(&opts, strings.NewReader("ok 10 March 2010\nbad 10/11/12\n"), "in.txt", &out)
+	if err == nil || !strings.HasPrefix(err.Error(), "in.txt:2:") {
+		t.Errorf("expected error on line 2, got %v", err)
+	}
+	if out.String() != "2010-03-10\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestRunLongLine(t *testing.T) {
+	defer func(n int) { maxLineLen = n }(maxLineLen)
+	maxLineLen = 64
+
+	input := "10 March 2010\n" + strings.Repeat("x", 100) + "\n"
+	opts := options{ctx: fuzzytime.DefaultContext, format: "iso"}
+	var out bytes.Buffer
+	err := run(&opts, strings.NewReader(input), "in.txt", &out)
+	if err == nil || !strings.HasPrefix(err.Error(), "in.txt:2:") {
+		t.Errorf("expected error on line 2, got %v", err)
+	}
+
+	opts.whole = true
+	out.Reset()
+	if err := run(&opts, strings.NewReader(input), "in.txt", &out); err != nil {
+		t.Errorf("-whole: unexpected error: %s", err)
+	}
+}
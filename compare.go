@@ -0,0 +1,179 @@
+package fuzzytime
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Ordering is the result of comparing two DateTimes
+type Ordering int
+
+const (
+	// OrderUnknown means the comparison couldn't be made (eg a year is missing)
+	OrderUnknown Ordering = iota
+	// OrderBefore means the first value is entirely before the second
+	OrderBefore
+	// OrderAfter means the first value is entirely after the second
+	OrderAfter
+	// OrderOverlap means the ranges covered by the two values overlap
+	// (this includes the case where they are equal)
+	OrderOverlap
+)
+
+// String returns the name of the ordering
+func (o Ordering) String() string {
+	switch o {
+	case OrderBefore:
+		return "before"
+	case OrderAfter:
+		return "after"
+	case OrderOverlap:
+		return "overlap"
+	}
+	return "unknown"
+}
+
+// maxTZOffset is the largest possible timezone offset either side of UTC
+const maxTZOffset = 14 * time.Hour
+
+// interval returns the range of instants covered by the datetime as
+// [start,end), in UTC. Fields beyond the first gap are ignored (so
+// "2010-??-03" covers all of 2010). A datetime without a timezone is
+// treated as UTC. Returns false if the year is unset.
+func (dt *DateTime) interval() (time.Time, time.Time, bool) {
+	first, last, err := dt.Date.Range()
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	loc := time.UTC
+	if dt.HasTZOffset() {
+		loc = time.FixedZone("", dt.TZOffset())
+	}
+	start := time.Date(first.Year(), time.Month(first.Month()), first.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(last.Year(), time.Month(last.Month()), last.Day()+1, 0, 0, 0, 0, loc)
+
+	// narrow down by the time, if it applies to a single day
+	if dt.HasFullDate() && dt.Period() == NoPeriod && !dt.Circa() && dt.HasHour() {
+		start = start.Add(time.Duration(dt.Hour()) * time.Hour)
+		unit := time.Hour
+		if dt.HasMinute() {
+			start = start.Add(time.Duration(dt.Minute()) * time.Minute)
+			unit = time.Minute
+			if dt.HasSecond() {
+				start = start.Add(time.Duration(dt.Second()) * time.Second)
+				unit = time.Second
+				if dt.HasFractional() {
+					start = start.Add(time.Duration(dt.Fractional()) * time.Millisecond)
+					unit = time.Millisecond
+				}
+			}
+		}
+		end = start.Add(unit)
+	}
+	return start.UTC(), end.UTC(), true
+}
+
+// Bounds returns the earliest and latest instants covered by the
+// datetime, in UTC. For example "2010-05" with a "+01:00" offset gives
+// 2010-04-30T23:00:00Z and 2010-05-31T22:59:59.999999999Z. If the
+// timezone is unknown, the range is widened by 14 hours either side to
+// cover all possible offsets.
+// Returns an error if the year is unset.
+func (dt *DateTime) Bounds() (earliest time.Time, latest time.Time, err error) {
+	start, end, ok := dt.interval()
+	if !ok {
+		return time.Time{}, time.Time{}, errors.New("datetime has no year")
+	}
+	if !dt.HasTZOffset() {
+		start, end = start.Add(-maxTZOffset), end.Add(maxTZOffset)
+	}
+	return start, end.Add(-time.Nanosecond), nil
+}
+
+// Compare works out the ordering of two datetimes from the ranges of
+// time they cover. For example, "2010-05" is before "2010-06-02",
+// and "2010" overlaps "2010-05". Timezone offsets are normalised if both
+// values have them. If only one does, the other is widened by the full
+// range of possible offsets (±14 hours). If neither does, they are
+// compared as if they were in the same zone.
+// Returns OrderUnknown if either value lacks a year.
+func (dt *DateTime) Compare(other *DateTime) Ordering {
+	aStart, aEnd, ok := dt.interval()
+	if !ok {
+		return OrderUnknown
+	}
+	bStart, bEnd, ok := other.interval()
+	if !ok {
+		return OrderUnknown
+	}
+	if dt.HasTZOffset() != other.HasTZOffset() {
+		if dt.HasTZOffset() {
+			bStart, bEnd = bStart.Add(-maxTZOffset), bEnd.Add(maxTZOffset)
+		} else {
+			aStart, aEnd = aStart.Add(-maxTZOffset), aEnd.Add(maxTZOffset)
+		}
+	}
+
+	switch {
+	case !aEnd.After(bStart):
+		return OrderBefore
+	case !bEnd.After(aStart):
+		return OrderAfter
+	}
+	return OrderOverlap
+}
+
+// Before returns true if dt is definitely before other (see Compare)
+func (dt *DateTime) Before(other *DateTime) bool {
+	return dt.Compare(other) == OrderBefore
+}
+
+// After returns true if dt is definitely after other (see Compare)
+func (dt *DateTime) After(other *DateTime) bool {
+	return dt.Compare(other) == OrderAfter
+}
+
+// SortDateTimes sorts datetimes into a timeline, by the start of the range
+// each covers. Where two start at the same time, the wider one comes
+// first (so "2010" sorts before "2010-01" which sorts before
+// "2010-01-01"). Values without a timezone are treated as UTC, and
+// values without a year go at the end. The sort is stable.
+func SortDateTimes(dts []DateTime) {
+	tl := timeline{dts: dts, keys: make([]timelineKey, len(dts))}
+	for i := range dts {
+		k := &tl.keys[i]
+		k.start, k.end, k.ok = dts[i].interval()
+	}
+	sort.Stable(tl)
+}
+
+// timelineKey holds the precalculated interval of a datetime, for sorting
+type timelineKey struct {
+	start, end time.Time
+	ok         bool
+}
+
+type timeline struct {
+	dts  []DateTime
+	keys []timelineKey
+}
+
+// implement sort.Interface
+func (tl timeline) Len() int { return len(tl.dts) }
+func (tl timeline) Swap(i, j int) {
+	tl.dts[i], tl.dts[j] = tl.dts[j], tl.dts[i]
+	tl.keys[i], tl.keys[j] = tl.keys[j], tl.keys[i]
+}
+func (tl timeline) Less(i, j int) bool {
+	a, b := tl.keys[i], tl.keys[j]
+	switch {
+	case a.ok != b.ok:
+		return a.ok
+	case !a.ok:
+		return false
+	case !a.start.Equal(b.start):
+		return a.start.Before(b.start)
+	}
+	return a.end.After(b.end)
+}
@@ -8,9 +8,27 @@ import (
 // unset.
 // default initialisation (ie Date{}) is a valid but empty Date.
 type Date struct {
-	year, month, day int // internally, we'll say 0=undefined
+	year, month, day int          // internally, we'll say 0=undefined
+	era              Era          // era system the year was converted from (if any)
+	orig             CalendarDate // original date, if converted from another calendar
+	penalty          int          // corrections made by fuzzy matching
+	period           Period       // season, quarter, decade etc (if any)
+	qualifier        Qualifier    // early/mid/late (if any)
+	uncertain        DateFields   // fields marked as uncertain ("2004?")
+	approx           DateFields   // fields marked as approximate ("circa 1850")
 }
 
+// DateFields is a set of flags identifying fields of a Date
+type DateFields int
+
+const (
+	YearField DateFields = 1 << iota
+	MonthField
+	DayField
+
+	AllDateFields = YearField | MonthField | DayField
+)
+
 // Year returns the year (result undefined if field unset)
 func (d *Date) Year() int { return d.year }
 
@@ -20,6 +38,36 @@ func (d *Date) Month() int { return d.month }
 // Day returns the day (result undefined if field unset)
 func (d *Date) Day() int { return d.day }
 
+// Era returns the era system in which the year was originally written,
+// or 0 if it was Gregorian. The year itself is always Gregorian.
+func (d *Date) Era() Era { return d.era }
+
+// Original returns the date as it was written, if it was converted from
+// a non-Gregorian calendar. The bool is false if there was no conversion.
+func (d *Date) Original() (CalendarDate, bool) { return d.orig, d.orig.Calendar != 0 }
+
+// Penalty returns the number of corrections (eg misspelt month names or
+// OCR errors) which were needed to parse the date. It is always zero
+// unless fuzzy matching was enabled (see Context.FuzzyNames).
+func (d *Date) Penalty() int { return d.penalty }
+
+// Period returns the period (season, quarter, decade...) the date refers
+// to, or NoPeriod.
+func (d *Date) Period() Period { return d.period }
+
+// Qualifier returns the early/mid/late qualifier, or NoQualifier.
+func (d *Date) Qualifier() Qualifier { return d.qualifier }
+
+// Circa returns true if any of the fields are marked as approximate.
+func (d *Date) Circa() bool { return d.approx != 0 }
+
+// Uncertain returns the fields which are marked as uncertain (eg the
+// year in "2004?-06-11")
+func (d *Date) Uncertain() DateFields { return d.uncertain }
+
+// Approximate returns the fields which are marked as approximate
+func (d *Date) Approximate() DateFields { return d.approx }
+
 // SetYear sets the year field
 func (d *Date) SetYear(year int) { d.year = year }
 
@@ -29,6 +77,30 @@ func (d *Date) SetMonth(month int) { d.month = month }
 // SetDay sets the day field
 func (d *Date) SetDay(day int) { d.day = day }
 
+// SetEra records the era system the year was converted from
+func (d *Date) SetEra(era Era) { d.era = era }
+
+// SetPeriod sets the period
+func (d *Date) SetPeriod(period Period) { d.period = period }
+
+// SetQualifier sets the early/mid/late qualifier
+func (d *Date) SetQualifier(qualifier Qualifier) { d.qualifier = qualifier }
+
+// SetCirca marks the whole date as approximate (or not)
+func (d *Date) SetCirca(circa bool) {
+	if circa {
+		d.approx = AllDateFields
+	} else {
+		d.approx = 0
+	}
+}
+
+// SetUncertain sets which fields are marked as uncertain
+func (d *Date) SetUncertain(fields DateFields) { d.uncertain = fields }
+
+// SetApproximate sets which fields are marked as approximate
+func (d *Date) SetApproximate(fields DateFields) { d.approx = fields }
+
 // HasYear returns true if the year is set
 func (d *Date) HasYear() bool { return d.year != 0 }
 
@@ -69,6 +141,7 @@ func (d *Date) Conflicts(other *Date) bool {
 func (d *Date) Merge(other *Date) {
 	if other.HasYear() {
 		d.SetYear(other.Year())
+		d.SetEra(other.Era())
 	}
 	if other.HasMonth() {
 		d.SetMonth(other.Month())
@@ -76,6 +149,17 @@ func (d *Date) Merge(other *Date) {
 	if other.HasDay() {
 		d.SetDay(other.Day())
 	}
+	if other.Period() != NoPeriod {
+		d.SetPeriod(other.Period())
+	}
+	if other.Qualifier() != NoQualifier {
+		d.SetQualifier(other.Qualifier())
+	}
+	d.uncertain |= other.uncertain
+	d.approx |= other.approx
+	if _, ok := other.Original(); ok {
+		d.orig = other.orig
+	}
 }
 
 // Empty tests if date is blank (ie all fields unset)
@@ -107,7 +191,7 @@ func (d *Date) sane() bool {
 func (d *Date) String() string {
 	var year, month, day = "????", "??", "??"
 	if d.HasYear() {
-		year = fmt.Sprintf("%04d", d.Year())
+		year = isoYear(d.Year())
 	}
 	if d.HasMonth() {
 		month = fmt.Sprintf("%02d", d.Month())
@@ -125,16 +209,25 @@ func (d *Date) ISOFormat() string {
 	if d.HasYear() {
 		if d.HasMonth() {
 			if d.HasDay() {
-				return fmt.Sprintf("%04d-%02d-%02d", d.Year(), d.Month(), d.Day())
+				return fmt.Sprintf("%s-%02d-%02d", isoYear(d.Year()), d.Month(), d.Day())
 			}
-			return fmt.Sprintf("%04d-%02d", d.Year(), d.Month())
+			return fmt.Sprintf("%s-%02d", isoYear(d.Year()), d.Month())
 		}
-		return fmt.Sprintf("%04d", d.Year())
+		return isoYear(d.Year())
 	}
 	return ""
 }
 
+// isoYear formats a year with at least 4 digits, and a leading '-' for
+// years BC (eg "2010", "-0005")
+func isoYear(year int) string {
+	if year < 0 {
+		return fmt.Sprintf("-%04d", -year)
+	}
+	return fmt.Sprintf("%04d", year)
+}
+
 // NewDate creates a Date with all fields set
 func NewDate(y, m, d int) *Date {
-	return &Date{y, m, d}
+	return &Date{year: y, month: m, day: d}
 }
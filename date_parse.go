@@ -4,11 +4,33 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// cjkYearPat matches the year part of a CJK date. The year is either four
+// digits, or a short era-style one with an optional era name (eg "平成26",
+// "令和元", "民國103", "103").
+var cjkYearPat = `(?:(?:(?P<era>明治|大正|昭和|平成|令和|中華民國|中华民国|民國|民国)[\s\p{Z}]*)?(?P<eyear>\d{1,3}|元)|(?P<year>\d{4}))[\s\p{Z}]*[年년]`
+
 // dateCracker is a set of regexps for various date formats
 // order is important(ish) - want to match as much of the string as we can
 var dateCrackers = []*regexp.Regexp{
+	// "2014年4月10日", "2014年4月10日（木）"
+	// "2014년 4월 10일"
+	// "平成26年4月10日", "民國103年4月10日"
+	regexp.MustCompile(cjkYearPat + `[\s\p{Z}]*(?P<month>\d{1,2})[\s\p{Z}]*[月월][\s\p{Z}]*(?P<day>\d{1,2})[\s\p{Z}]*[日일](?:[\s\p{Z}]*[(（](?P<dayname>\p{L}+)[)）])?`),
+
+	// "2014年4月", "2014년 4월"
+	regexp.MustCompile(cjkYearPat + `[\s\p{Z}]*(?P<month>\d{1,2})[\s\p{Z}]*[月월]`),
+
+	// Thai, with optional era marker
+	// "10 เมษายน 2557", "10 เม.ย. พ.ศ. 2557"
+	regexp.MustCompile(`(?P<day>\d{1,2})[\s\p{Z}]+(?P<month>[\p{Thai}.]+)[\s\p{Z}]+(?:(?P<era>[พค]\.ศ\.?)[\s\p{Z}]*)?(?P<year>\d{4})`),
+
+	// "4月10日", "4월 10일"
+	regexp.MustCompile(`(?P<month>\d{1,2})[\s\p{Z}]*[月월][\s\p{Z}]*(?P<day>\d{1,2})[\s\p{Z}]*[日일]`),
+
 	//"Tuesday 16 December 2008"
 	//"Tue 29 Jan 08"
 	//"Monday, 22 October 2007"
@@ -37,6 +59,9 @@ var dateCrackers = []*regexp.Regexp{
 	// "09-Apr-2007", "09-Apr-07"
 	regexp.MustCompile(`(?i)(?P<day>\d{1,2})-(?P<month>\p{L}{3,})-(?P<year>(\d{4})|(\d{2}))`),
 
+	// "early May 2011", "mid-March 2010", "late Jan 2008"
+	regexp.MustCompile(`(?i)\b(?P<qualifier>early|mid|middle|late)[-\s\p{Z}]+(?P<month>\p{L}{3,})[.,\s\p{Z}]+(?P<year>\d{4})`),
+
 	// "May 2011"
 	regexp.MustCompile(`(?i)(?P<month>\p{L}{3,})[\s\p{Z}]+(?P<year>\d{4})`),
 
@@ -65,6 +90,39 @@ var dateCrackers = []*regexp.Regexp{
 
 	// April 24th
 	regexp.MustCompile(`(?i)(?P<month>\p{L}{3,})[.,\s\p{Z}]+(?P<day>\d{1,2})(?:st|nd|rd|th)?`),
+
+	// periods and approximations
+
+	// "spring 2009", "Q3 2011", "H1 2015", "second half of 2012"
+	regexp.MustCompile(`(?i)\b(?P<period>spring|summer|autumn|fall|winter|[qh]\d|(?:first|second|third|fourth|1st|2nd|3rd|4th)[\s\p{Z}]+(?:quarter|half))(?:[\s\p{Z}]+of)?[,\s\p{Z}]+(?P<year>\d{4})`),
+
+	// "2011 Q3", "2011-Q3", "2015 H1"
+	regexp.MustCompile(`(?i)\b(?P<year>\d{4})[-\s\p{Z}]*(?P<period>[qh]\d)\b`),
+
+	// "the 1980s", "late 1990s", "the '60s", "the 1800s"
+	regexp.MustCompile(`(?i)(?:\b(?P<qualifier>early|mid|middle|late)[-\s\p{Z}]+)?(?P<decade>\d{3}0|'\d0)'?s\b`),
+
+	// "19th century", "the early 20th century"
+	regexp.MustCompile(`(?i)(?:\b(?P<qualifier>early|mid|middle|late)[-\s\p{Z}]+)?(?P<century>\d{1,2})(?:st|nd|rd|th)[-\s\p{Z}]+century`),
+
+	// "circa 1850", "c. 1850", "ca. 1850"
+	regexp.MustCompile(`(?P<circa>\b(?:[Cc]irca|[Cc]a?\.))[\s\p{Z}]*(?P<year>\d{4})`),
+
+	// "early 2010", "mid-2010"
+	regexp.MustCompile(`(?i)\b(?P<qualifier>early|mid|middle|late)[-\s\p{Z}]+(?P<year>\d{4})\b`),
+}
+
+// shortPeriodStart returns true if a match with a short period ("Q3 2011",
+// "2015 H1") starting at pos begins a word (regexp's \b is ASCII-only) and
+// doesn't follow a number (more likely a phone number, code or suchlike).
+func shortPeriodStart(s string, pos int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:pos])
+	if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+		return false
+	}
+	prev := strings.TrimRightFunc(s[:pos], unicode.IsSpace)
+	r, _ = utf8.DecodeLastRuneInString(prev)
+	return !unicode.IsDigit(r)
 }
 
 // ExtendYear extends 2-digit years into 4 digits.
@@ -85,6 +143,36 @@ func ExtendYear(year int) int {
 // It returns a Date and Span indicating which part of string matched.
 // If an error occurs, an empty Date will be returned.
 func (ctx *Context) ExtractDate(s string) (Date, Span, error) {
+	fd, span, _, err := ctx.extractDateDetailed(s)
+	if err != nil {
+		return Date{}, Span{}, err
+	}
+	return fd, span, nil
+}
+
+// extractDateDetailed is ExtractDate, but also returns the spans of the
+// individual fields (without their text). If an error occurs, the span
+// is that of the text at fault.
+func (ctx *Context) extractDateDetailed(s string) (Date, Span, Fields, error) {
+	norm, om := normalise(s)
+	fd, span, fields, err := ctx.extractDate(norm)
+	fields.remap(om)
+	return fd, om.span(span), fields, err
+}
+
+// extractDate does the work for ExtractDate, on a normalised string
+func (ctx *Context) extractDate(s string) (Date, Span, Fields, error) {
+	var ocrFixes []int // offsets of any OCR corrections
+	if ctx.FuzzyNames {
+		s, ocrFixes = ocrCorrect(s)
+	}
+
+	if ctx.Calendars != 0 {
+		if fd, span, fields, ok := ctx.extractCalendarDate(s); ok {
+			fd.penalty = countOffsets(ocrFixes, span)
+			return fd, span, fields, nil
+		}
+	}
 
 	for _, pat := range dateCrackers {
 		fd := Date{}
@@ -97,16 +185,28 @@ func (ctx *Context) ExtractDate(s string) (Date, Span, error) {
 
 		var fail bool
 
+		var fields Fields
 		unknowns := make([]int, 0, 3) // for ambiguous components
+		var unknownSpans []Span       // and where they were
+		var eraName string            // era marker, if any
+		var shortYear bool            // era-style year (eg "26年")?
+		var penalty int               // corrections made in fuzzy matching
+		var notDayname Span           // dayname which isn't a weekday
 		for i, name := range names {
 			start, end := matchSpans[i*2], matchSpans[(i*2)+1]
+			if start < 0 {
+				continue // optional part, not matched
+			}
 			var sub string
 			if start >= 0 && end >= 0 {
 				sub = strings.ToLower(s[start:end])
 			}
 
+			fieldSpan := Span{start, end}
+
 			switch name {
 			case "year":
+				fields.Year.Span = fieldSpan
 				year, e := strconv.Atoi(sub)
 				if e == nil {
 					year = ExtendYear(year)
@@ -115,7 +215,23 @@ func (ctx *Context) ExtractDate(s string) (Date, Span, error) {
 					fail = true
 					break
 				}
+			case "era":
+				eraName = sub
+			case "eyear":
+				fields.Year.Span = fieldSpan
+				year := 1 // "元" (first year of era)
+				if sub != "元" {
+					var e error
+					year, e = strconv.Atoi(sub)
+					if e != nil {
+						fail = true
+						break
+					}
+				}
+				fd.SetYear(year)
+				shortYear = true
 			case "month":
+				fields.Month.Span = fieldSpan
 				month, e := strconv.Atoi(sub)
 				if e == nil {
 					// it was a number
@@ -127,6 +243,11 @@ func (ctx *Context) ExtractDate(s string) (Date, Span, error) {
 				} else {
 					// try month name
 					month, ok := monthLookup[sub]
+					if !ok {
+						var edits int
+						month, edits, ok = ctx.lookupLocaleName(sub, false)
+						penalty += edits
+					}
 					if !ok {
 						fail = true
 						break // nope.
@@ -141,7 +262,23 @@ func (ctx *Context) ExtractDate(s string) (Date, Span, error) {
 					fail = true
 					break
 				}
+			case "dayname":
+				// not needed, but the pattern takes any word, so only
+				// count it as part of the date if it's a known weekday
+				// (and count any corrections when fuzzy matching)
+				_, ok := dayLookup[sub]
+				if !ok {
+					var edits int
+					_, edits, ok = ctx.lookupLocaleName(sub, true)
+					penalty += edits
+				}
+				if ok {
+					fields.Weekday.Span = fieldSpan
+				} else {
+					notDayname = fieldSpan
+				}
 			case "day":
+				fields.Day.Span = fieldSpan
 				day, e := strconv.Atoi(sub)
 				if e != nil {
 					fail = true
@@ -152,6 +289,54 @@ func (ctx *Context) ExtractDate(s string) (Date, Span, error) {
 					break
 				}
 				fd.SetDay(day)
+			case "qualifier":
+				fd.SetQualifier(qualifierLookup[sub])
+			case "period":
+				period, ok := periodLookup[strings.Join(strings.Fields(sub), " ")]
+				if !ok {
+					fail = true
+					break
+				}
+				if len(sub) == 2 && !shortPeriodStart(s, matchSpans[0]) {
+					// "call 555 1234 h1 2015" isn't a period
+					fail = true
+					break
+				}
+				fd.SetPeriod(period)
+			case "decade":
+				fields.Year.Span = fieldSpan
+				year, e := strconv.Atoi(strings.TrimPrefix(sub, "'"))
+				if e != nil {
+					fail = true
+					break
+				}
+				if year < 100 {
+					// "the '60s" is much more likely than "the 2060s"
+					if year < 30 {
+						year += 2000
+					} else {
+						year += 1900
+					}
+				}
+				if year%100 == 0 && year < 2000 {
+					// "the 1800s" usually means the century
+					fd.SetPeriod(Century)
+				} else {
+					fd.SetPeriod(Decade)
+				}
+				fd.SetYear(year)
+			case "century":
+				fields.Year.Span = fieldSpan
+				// colloquial, so "19th century" is 1800-1899
+				n, e := strconv.Atoi(sub)
+				if e != nil || n < 2 {
+					fail = true
+					break
+				}
+				fd.SetYear((n - 1) * 100)
+				fd.SetPeriod(Century)
+			case "circa":
+				fd.SetCirca(true)
 			case "x1", "x2", "x3":
 				// could be day, month or year...
 				x, e := strconv.Atoi(sub)
@@ -160,6 +345,7 @@ func (ctx *Context) ExtractDate(s string) (Date, Span, error) {
 					break
 				}
 				unknowns = append(unknowns, x)
+				unknownSpans = append(unknownSpans, fieldSpan)
 			}
 		}
 
@@ -168,33 +354,49 @@ func (ctx *Context) ExtractDate(s string) (Date, Span, error) {
 			continue
 		}
 
+		if !ctx.resolveEra(&fd, eraName, shortYear) {
+			// era not enabled in this context
+			continue
+		}
+
 		// got enough?
-		if (fd.HasYear() && fd.HasMonth()) || (fd.HasMonth() && fd.HasDay()) {
+		approx := fd.Period() != NoPeriod || fd.Qualifier() != NoQualifier || fd.Circa()
+		if (fd.HasYear() && fd.HasMonth()) || (fd.HasMonth() && fd.HasDay()) || (fd.HasYear() && approx) {
 			if fd.sane() {
 				span.Begin, span.End = matchSpans[0], matchSpans[1]
-				return fd, span, nil
+				if notDayname.End > notDayname.Begin && notDayname.Begin == span.Begin {
+					// start at the date proper instead
+					span.Begin = coreDateSpan(span, &fields).Begin
+				}
+				fd.penalty = penalty + countOffsets(ocrFixes, span)
+				return fd, span, fields, nil
 			}
 		} else {
 			// got some ambiguous components to try?
 			if len(unknowns) == 2 && fd.HasYear() {
 				unknowns = append(unknowns, fd.Year())
+				unknownSpans = append(unknownSpans, fields.Year.Span)
 			}
 			if len(unknowns) == 3 {
+				era := fd.Era()
 				var err error
 				fd, err = ctx.DateResolver(unknowns[0], unknowns[1], unknowns[2])
 				if err != nil {
-					return Date{}, Span{}, err
+					return Date{}, Span{matchSpans[0], matchSpans[1]}, Fields{}, err
 				}
+				fd.SetEra(era)
 
 				if fd.HasYear() && fd.HasMonth() && fd.HasDay() && fd.sane() {
 					// resolved.
 					span.Begin, span.End = matchSpans[0], matchSpans[1]
-					return fd, span, nil
+					fd.penalty = countOffsets(ocrFixes, span)
+					fields.resolveUnknowns(&fd, unknowns, unknownSpans)
+					return fd, span, fields, nil
 				}
 			}
 		}
 	}
 
 	// nothing. Just return an empty date and span
-	return Date{}, Span{}, nil
+	return Date{}, Span{}, Fields{}, nil
 }
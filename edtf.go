@@ -0,0 +1,221 @@
+package fuzzytime
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EDTF support.
+// The Extended Date/Time Format (ISO 8601-2, from the Library of Congress)
+// can express things ISOFormat() can't: unset fields ("2004-XX-11"),
+// uncertainty ("2004?"), approximation ("2004-06~"), intervals
+// ("1984/2004") and sets ("[1667,1668,1670..1672]").
+// See https://www.loc.gov/standards/datetime/
+
+// EDTFKind identifies which sort of value an EDTF string holds
+type EDTFKind int
+
+const (
+	EDTFDateTime EDTFKind = iota // a single date (and maybe time)
+	EDTFInterval                 // "1984/2004"
+	EDTFOneOf                    // "[1667,1668]" - one of the set
+	EDTFAllOf                    // "{1667,1668}" - all of the set
+)
+
+// Interval represents a range between two datetimes.
+// An empty Start or End means that end is unknown (eg "1984/").
+type Interval struct {
+	Start DateTime
+	End   DateTime
+	// StartOpen and EndOpen are set for open-ended intervals (eg "1984/..")
+	StartOpen bool
+	EndOpen   bool
+}
+
+// EDTF holds a parsed EDTF value (see ParseEDTF).
+type EDTF struct {
+	Kind EDTFKind
+	// DateTime is set for EDTFDateTime
+	DateTime DateTime
+	// Interval is set for EDTFInterval
+	Interval Interval
+	// Set holds the members of EDTFOneOf and EDTFAllOf sets. Single
+	// dates are held as intervals with Start and End the same.
+	Set []Interval
+}
+
+// EDTFFormat returns the date as an EDTF string. Unset fields are
+// written as X's (eg "XXXX-04-10", "2004-XX-11"), decades and centuries
+// as "201X" and "19XX", and seasons, quarters and halves using the
+// EDTF month codes (21-24, 33-36 and 40-41). Returns "" if the date is
+// empty.
+// The early/mid/late qualifier can't be expressed and is dropped.
+func (d *Date) EDTFFormat() string {
+	if d.Empty() {
+		return ""
+	}
+
+	parts := []string{edtfYear(d)}
+	flags := []DateFields{YearField}
+
+	month := ""
+	if code, ok := edtfPeriodCodes[d.Period()]; ok {
+		month = fmt.Sprintf("%02d", code)
+	} else if d.HasMonth() {
+		month = fmt.Sprintf("%02d", d.Month())
+	} else if d.HasDay() {
+		month = "XX"
+	}
+	if month != "" {
+		parts = append(parts, month)
+		flags = append(flags, MonthField)
+	}
+	if d.HasDay() {
+		parts = append(parts, fmt.Sprintf("%02d", d.Day()))
+		flags = append(flags, DayField)
+	}
+
+	// work out the qualifier for each component
+	quals := make([]string, len(parts))
+	for i, f := range flags {
+		quals[i] = edtfQualifier(d.uncertain&f != 0, d.approx&f != 0)
+	}
+
+	// if the qualified components are all on the left and share a
+	// qualifier, a single suffix will do (eg "2004?-06-11", "2004-06~").
+	// Otherwise each component gets its own prefix (eg "2004-?06-11").
+	last := -1
+	for i := range quals {
+		if quals[i] != "" {
+			last = i
+		}
+	}
+	suffix := last >= 0
+	for i := 0; i <= last; i++ {
+		if quals[i] != quals[last] {
+			suffix = false
+		}
+	}
+	if suffix {
+		parts[last] += quals[last]
+	} else {
+		for i := range parts {
+			parts[i] = quals[i] + parts[i]
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// edtfYear formats the year part of an EDTF date
+func edtfYear(d *Date) string {
+	if !d.HasYear() {
+		return "XXXX"
+	}
+	var year string
+	y := d.Year()
+	switch {
+	case y > 9999 || y < -9999:
+		return fmt.Sprintf("Y%d", y)
+	case y < 0:
+		year = fmt.Sprintf("-%04d", -y)
+	default:
+		year = fmt.Sprintf("%04d", y)
+	}
+	switch d.Period() {
+	case Decade:
+		year = year[:len(year)-1] + "X"
+	case Century:
+		year = year[:len(year)-2] + "XX"
+	}
+	return year
+}
+
+// edtfQualifier returns the EDTF qualifier character for a component
+func edtfQualifier(uncertain, approx bool) string {
+	switch {
+	case uncertain && approx:
+		return "%"
+	case uncertain:
+		return "?"
+	case approx:
+		return "~"
+	}
+	return ""
+}
+
+// EDTFFormat returns the time in EDTF (ie ISO8601) form, "hh:mm:ss" with
+// optional fractional seconds and timezone. EDTF has no reduced precision
+// times, so returns "" unless the hour, minute and second are all set.
+func (t *Time) EDTFFormat() string {
+	if !t.HasHour() || !t.HasMinute() || !t.HasSecond() {
+		return ""
+	}
+	return t.ISOFormat()
+}
+
+// EDTFFormat returns the datetime as an EDTF string. EDTF only allows
+// complete times on complete dates, so the time is only included if the
+// year, month, day, hour, minute and second are all set.
+func (dt *DateTime) EDTFFormat() string {
+	out := dt.Date.EDTFFormat()
+	if dt.HasFullDate() {
+		if t := dt.Time.EDTFFormat(); t != "" {
+			out += "T" + t
+		}
+	}
+	return out
+}
+
+// EDTFFormat returns the interval as an EDTF string (eg "1984/2004",
+// "1984/..", "/2004")
+func (iv *Interval) EDTFFormat() string {
+	return iv.edtfEnd(&iv.Start, iv.StartOpen) + "/" + iv.edtfEnd(&iv.End, iv.EndOpen)
+}
+
+func (iv *Interval) edtfEnd(dt *DateTime, open bool) string {
+	if open {
+		return ".."
+	}
+	return dt.EDTFFormat()
+}
+
+// String returns the EDTF form of the value
+func (e *EDTF) String() string {
+	switch e.Kind {
+	case EDTFDateTime:
+		return e.DateTime.EDTFFormat()
+	case EDTFInterval:
+		return e.Interval.EDTFFormat()
+	}
+	members := make([]string, len(e.Set))
+	for i, iv := range e.Set {
+		switch {
+		case !iv.StartOpen && !iv.EndOpen && iv.Start.Equals(&iv.End):
+			members[i] = iv.Start.EDTFFormat()
+		case iv.StartOpen:
+			members[i] = ".." + iv.End.EDTFFormat()
+		case iv.EndOpen:
+			members[i] = iv.Start.EDTFFormat() + ".."
+		default:
+			members[i] = iv.Start.EDTFFormat() + ".." + iv.End.EDTFFormat()
+		}
+	}
+	if e.Kind == EDTFAllOf {
+		return "{" + strings.Join(members, ",") + "}"
+	}
+	return "[" + strings.Join(members, ",") + "]"
+}
+
+// edtfPeriodCodes maps periods onto EDTF month codes
+var edtfPeriodCodes = map[Period]int{
+	Spring: 21,
+	Summer: 22,
+	Autumn: 23,
+	Winter: 24,
+	Q1:     33,
+	Q2:     34,
+	Q3:     35,
+	Q4:     36,
+	H1:     40,
+	H2:     41,
+}
@@ -0,0 +1,301 @@
+package fuzzytime
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// edtfDateRE matches a single EDTF date (and optional "hh:mm:ss" time).
+// Each date component can be preceded by a qualifier, which applies to
+// that component only, or followed by one, which applies to it and all
+// the components to its left.
+var edtfDateRE = regexp.MustCompile(`^(?P<yearq>[?~%])?(?P<year>Y-?\d{5,}|-?[\dX]{4})(?P<yearqs>[?~%])?` +
+	`(?:-(?P<monthq>[?~%])?(?P<month>[\dX]{2})(?P<monthqs>[?~%])?` +
+	`(?:-(?P<dayq>[?~%])?(?P<day>[\dX]{2})(?P<dayqs>[?~%])?)?)?` +
+	`(?:T(?P<hour>\d{2}):(?P<minute>\d{2}):(?P<second>\d{2})(?:\.(?P<fractional>\d{1,3}))?(?P<tz>Z|[+-]\d{2}(?::?\d{2})?)?)?$`)
+
+// edtfMonthCodes maps the EDTF month codes for seasons, quarters and
+// halves onto Periods. 25-28 are the northern hemisphere seasons,
+// which are what we use anyway.
+var edtfMonthCodes = map[int]Period{
+	21: Spring, 22: Summer, 23: Autumn, 24: Winter,
+	25: Spring, 26: Summer, 27: Autumn, 28: Winter,
+	33: Q1, 34: Q2, 35: Q3, 36: Q4,
+	40: H1, 41: H2,
+}
+
+// ParseEDTF parses a string in the Extended Date/Time Format, which can
+// hold a single date ("2004-06~", "2004?-06", "201X"), an interval
+// ("1984/2004", "1984/..") or a set ("[1667,1668,1670..1672]",
+// "{1960,1961-12}").
+// Supported are EDTF levels 0 and 1, plus the level 2 features which
+// can be represented by fuzzytime: per-component qualifiers ("2004-?06"),
+// sets, and quarters and halves (month codes 33-36 and 40-41).
+// Unspecified digits are only supported at the end of the year
+// ("201X", "19XX", "XXXX") and for whole months or days ("2004-XX-11").
+func ParseEDTF(s string) (EDTF, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return EDTF{}, errors.New("empty EDTF string")
+	}
+
+	switch s[0] {
+	case '[':
+		return parseEDTFSet(s, '[', ']', EDTFOneOf)
+	case '{':
+		return parseEDTFSet(s, '{', '}', EDTFAllOf)
+	}
+
+	if strings.Contains(s, "/") {
+		parts := strings.Split(s, "/")
+		if len(parts) != 2 || (parts[0] == "" && parts[1] == "") {
+			return EDTF{}, errors.New("bad EDTF interval")
+		}
+		var iv Interval
+		var err error
+		iv.Start, iv.StartOpen, err = parseEDTFEnd(parts[0])
+		if err != nil {
+			return EDTF{}, err
+		}
+		iv.End, iv.EndOpen, err = parseEDTFEnd(parts[1])
+		if err != nil {
+			return EDTF{}, err
+		}
+		return EDTF{Kind: EDTFInterval, Interval: iv}, nil
+	}
+
+	dt, err := parseEDTFDateTime(s)
+	if err != nil {
+		return EDTF{}, err
+	}
+	return EDTF{Kind: EDTFDateTime, DateTime: dt}, nil
+}
+
+// parseEDTFEnd parses one end of an interval, which can be a date,
+// ".." (open) or "" (unknown)
+func parseEDTFEnd(s string) (DateTime, bool, error) {
+	switch s {
+	case "..":
+		return DateTime{}, true, nil
+	case "":
+		return DateTime{}, false, nil
+	}
+	dt, err := parseEDTFDateTime(s)
+	return dt, false, err
+}
+
+// parseEDTFSet parses "[a,b,c..d]" or "{a,b,c..d}"
+func parseEDTFSet(s string, open, close byte, kind EDTFKind) (EDTF, error) {
+	if len(s) < 2 || s[0] != open || s[len(s)-1] != close {
+		return EDTF{}, errors.New("bad EDTF set")
+	}
+	out := EDTF{Kind: kind}
+	for _, member := range strings.Split(s[1:len(s)-1], ",") {
+		member = strings.TrimSpace(member)
+		var iv Interval
+		var err error
+		if idx := strings.Index(member, ".."); idx >= 0 {
+			a, b := member[:idx], member[idx+2:]
+			if a == "" && b == "" {
+				return EDTF{}, errors.New("bad EDTF set")
+			}
+			iv.StartOpen, iv.EndOpen = a == "", b == ""
+			if a != "" {
+				if iv.Start, err = parseEDTFDateTime(a); err != nil {
+					return EDTF{}, err
+				}
+			}
+			if b != "" {
+				if iv.End, err = parseEDTFDateTime(b); err != nil {
+					return EDTF{}, err
+				}
+			}
+		} else {
+			if iv.Start, err = parseEDTFDateTime(member); err != nil {
+				return EDTF{}, err
+			}
+			iv.End = iv.Start
+		}
+		out.Set = append(out.Set, iv)
+	}
+	return out, nil
+}
+
+// parseEDTFDateTime parses a single EDTF date, with optional time
+func parseEDTFDateTime(s string) (DateTime, error) {
+	m := edtfDateRE.FindStringSubmatch(s)
+	if m == nil {
+		return DateTime{}, errors.New("bad EDTF date")
+	}
+
+	var dt DateTime
+	var uncertain, approx DateFields
+	qualify := func(q string, fields DateFields) {
+		switch q {
+		case "?":
+			uncertain |= fields
+		case "~":
+			approx |= fields
+		case "%":
+			uncertain |= fields
+			approx |= fields
+		}
+	}
+
+	for i, name := range edtfDateRE.SubexpNames() {
+		sub := m[i]
+		if sub == "" {
+			continue
+		}
+		switch name {
+		case "year":
+			if err := parseEDTFYear(&dt.Date, sub); err != nil {
+				return DateTime{}, err
+			}
+		case "yearq", "yearqs":
+			qualify(sub, YearField)
+		case "month":
+			if sub == "XX" {
+				break
+			}
+			month, err := strconv.Atoi(sub)
+			if err != nil {
+				return DateTime{}, errors.New("bad EDTF month")
+			}
+			if month >= 1 && month <= 12 {
+				dt.SetMonth(month)
+			} else if period, ok := edtfMonthCodes[month]; ok && dt.Period() == NoPeriod {
+				dt.SetPeriod(period)
+			} else {
+				return DateTime{}, errors.New("bad EDTF month")
+			}
+		case "monthq":
+			qualify(sub, MonthField)
+		case "monthqs":
+			qualify(sub, YearField|MonthField)
+		case "day":
+			if sub == "XX" {
+				break
+			}
+			day, err := strconv.Atoi(sub)
+			if err != nil || day < 1 || day > 31 || dt.Period() != NoPeriod {
+				return DateTime{}, errors.New("bad EDTF day")
+			}
+			dt.SetDay(day)
+		case "dayq":
+			qualify(sub, DayField)
+		case "dayqs":
+			qualify(sub, AllDateFields)
+		case "hour", "minute", "second", "fractional":
+			if !dt.HasFullDate() {
+				return DateTime{}, errors.New("EDTF time requires a full date")
+			}
+			if err := parseEDTFTimeField(&dt.Time, name, sub); err != nil {
+				return DateTime{}, err
+			}
+		case "tz":
+			offset, err := TZToOffset(sub)
+			if err != nil {
+				return DateTime{}, err
+			}
+			dt.SetTZOffset(offset)
+		}
+	}
+	// a qualifier after the last component applies to the whole date
+	// (as for SetCirca)
+	names := map[string]string{}
+	for i, name := range edtfDateRE.SubexpNames() {
+		names[name] = m[i]
+	}
+	switch {
+	case names["day"] != "":
+		qualify(names["dayqs"], AllDateFields)
+	case names["month"] != "":
+		qualify(names["monthqs"], AllDateFields)
+	default:
+		qualify(names["yearqs"], AllDateFields)
+	}
+	dt.SetUncertain(uncertain)
+	dt.SetApproximate(approx)
+	return dt, nil
+}
+
+// parseEDTFYear parses an EDTF year, including "Y" years
+// ("Y170000002") and unspecified digits ("201X", "19XX", "XXXX")
+func parseEDTFYear(d *Date, s string) error {
+	if s[0] == 'Y' {
+		year, err := strconv.Atoi(s[1:])
+		if err != nil {
+			return errors.New("bad EDTF year")
+		}
+		d.SetYear(year)
+		return nil
+	}
+
+	digits := strings.TrimRight(s, "X")
+	var period Period
+	switch len(s) - len(digits) {
+	case 0:
+	case 1:
+		period = Decade
+	case 2:
+		period = Century
+	case 4:
+		return nil // year unspecified
+	default:
+		return errors.New("unsupported EDTF year")
+	}
+	if strings.Contains(digits, "X") || (period != NoPeriod && digits[0] == '-') {
+		return errors.New("unsupported EDTF year")
+	}
+	year, err := strconv.Atoi(digits)
+	if err != nil {
+		return errors.New("bad EDTF year")
+	}
+	switch period {
+	case Decade:
+		year *= 10
+	case Century:
+		year *= 100
+	}
+	if year == 0 {
+		return errors.New("year zero is not supported")
+	}
+	d.SetYear(year)
+	d.SetPeriod(period)
+	return nil
+}
+
+// parseEDTFTimeField sets one of the time fields
+func parseEDTFTimeField(t *Time, name string, s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("bad EDTF time")
+	}
+	switch name {
+	case "hour":
+		if v > 23 {
+			return errors.New("bad EDTF hour")
+		}
+		t.SetHour(v)
+	case "minute":
+		if v > 59 {
+			return errors.New("bad EDTF minute")
+		}
+		t.SetMinute(v)
+	case "second":
+		if v > 59 {
+			return errors.New("bad EDTF second")
+		}
+		t.SetSecond(v)
+	case "fractional":
+		// "5" is 500ms
+		for i := len(s); i < 3; i++ {
+			v *= 10
+		}
+		t.SetFractional(v)
+	}
+	return nil
+}
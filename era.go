@@ -0,0 +1,78 @@
+package fuzzytime
+
+import (
+	"fmt"
+)
+
+// Era identifies a year numbering system other than the usual Gregorian
+// one. Eras are bit flags, so a Context can enable several at once.
+type Era int
+
+const (
+	// JapaneseEra is the Japanese imperial era system (eg "平成26年", "令和元年")
+	JapaneseEra Era = 1 << iota
+	// MinguoEra is the Republic of China (Taiwan) system (eg "民國103年")
+	MinguoEra
+	// BuddhistEra is the Thai Buddhist Era (eg "พ.ศ. 2557")
+	BuddhistEra
+)
+
+// String returns the name of the era system
+func (era Era) String() string {
+	switch era {
+	case 0:
+		return "Gregorian"
+	case JapaneseEra:
+		return "Japanese"
+	case MinguoEra:
+		return "Minguo"
+	case BuddhistEra:
+		return "Buddhist"
+	}
+	return fmt.Sprintf("Era(%d)", int(era))
+}
+
+// earliest Buddhist Era year we'll assume when there is no explicit era
+// marker (2400 BE is 1857 AD).
+const minBareBuddhistYear = 2400
+
+// resolveEra converts the year in fd to Gregorian if it was numbered in an
+// era. eraName is the era marker found with the year (if any) and short
+// is set if the year was a short, era-style one (eg the "103" in "103年").
+// Returns false if the year can't be interpreted under the eras enabled in
+// ctx.
+func (ctx *Context) resolveEra(fd *Date, eraName string, short bool) bool {
+	if !fd.HasYear() {
+		return true
+	}
+	if eraName != "" {
+		info, ok := eraLookup[eraName]
+		if !ok {
+			return false
+		}
+		if info.era == 0 {
+			// explicitly Gregorian
+			return true
+		}
+		if ctx.Eras&info.era == 0 {
+			return false
+		}
+		fd.SetYear(info.offset + fd.Year())
+		fd.SetEra(info.era)
+		return true
+	}
+	if short {
+		// short year with no era marker - only makes sense as Minguo
+		if ctx.Eras&MinguoEra == 0 {
+			return false
+		}
+		fd.SetYear(minguoOffset + fd.Year())
+		fd.SetEra(MinguoEra)
+		return true
+	}
+	if ctx.Eras&BuddhistEra != 0 && fd.Year() >= minBareBuddhistYear {
+		fd.SetYear(buddhistOffset + fd.Year())
+		fd.SetEra(BuddhistEra)
+	}
+	return true
+}
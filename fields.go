@@ -0,0 +1,109 @@
+package fuzzytime
+
+// FieldSpan is the part of a string a single component of a date or time
+// was parsed from, along with the raw text.
+type FieldSpan struct {
+	Span
+	Text string
+}
+
+// Fields holds a FieldSpan for each component of an extracted datetime.
+// Components which weren't present in the text have an empty FieldSpan.
+// Decades and centuries ("the 1980s", "19th century") count as years.
+// For dates in non-Gregorian calendars, the spans are of the components
+// as written (see Date.Original).
+type Fields struct {
+	Year    FieldSpan
+	Month   FieldSpan
+	Day     FieldSpan
+	Weekday FieldSpan
+
+	Hour       FieldSpan
+	Minute     FieldSpan
+	Second     FieldSpan
+	Fractional FieldSpan
+	AMPM       FieldSpan // "am", "p.m." etc
+	TZ         FieldSpan
+}
+
+// all returns pointers to all the fields, for iterating over
+func (f *Fields) all() []*FieldSpan {
+	return []*FieldSpan{
+		&f.Year, &f.Month, &f.Day, &f.Weekday,
+		&f.Hour, &f.Minute, &f.Second, &f.Fractional, &f.AMPM, &f.TZ,
+	}
+}
+
+// merge copies in any fields set in other
+func (f *Fields) merge(other *Fields) {
+	dest := f.all()
+	for i, fs := range other.all() {
+		if fs.Begin != fs.End {
+			*dest[i] = *fs
+		}
+	}
+}
+
+// remap converts the spans from a normalised string back to the original
+func (f *Fields) remap(om offsetMap) {
+	for _, fs := range f.all() {
+		fs.Span = om.span(fs.Span)
+	}
+}
+
+// setText fills in the raw text of each field from s
+func (f *Fields) setText(s string) {
+	for _, fs := range f.all() {
+		fs.Text = s[fs.Begin:fs.End]
+	}
+}
+
+// resolveUnknowns works out which of the ambiguous components of a
+// date (eg "10/11/12") ended up as the year, month and day, by matching
+// up the values the DateResolver returned. Where more than one layout
+// fits (eg "05/05/05"), the more common layouts are preferred.
+func (f *Fields) resolveUnknowns(fd *Date, unknowns []int, spans []Span) {
+	if len(unknowns) != 3 || len(spans) != 3 {
+		return
+	}
+	matches := func(x int, field int) bool {
+		switch field {
+		case 0:
+			return x == fd.Year() || ExtendYear(x) == fd.Year()
+		case 1:
+			return x == fd.Month()
+		}
+		return x == fd.Day()
+	}
+	// layouts, as the field (0=year, 1=month, 2=day) for each position
+	layouts := [][3]int{{2, 1, 0}, {1, 2, 0}, {0, 1, 2}, {0, 2, 1}, {2, 0, 1}, {1, 0, 2}}
+	for _, layout := range layouts {
+		ok := true
+		for i, field := range layout {
+			if !matches(unknowns[i], field) {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		dest := []*FieldSpan{&f.Year, &f.Month, &f.Day}
+		for i, field := range layout {
+			dest[field].Span = spans[i]
+		}
+		return
+	}
+}
+
+// ExtractDetailed is like Extract, but also returns the spans of the
+// individual components (year, month, day, weekday, hour etc).
+// If none found, the returned DateTime will be empty.
+func (ctx *Context) ExtractDetailed(s string) (Match, error) {
+	return ctx.extract(s)
+}
+
+// ExtractDetailed is like Extract, but also returns the spans of the
+// individual components.
+// Equivalent to DefaultContext.ExtractDetailed()
+func ExtractDetailed(s string) (Match, error) { return DefaultContext.ExtractDetailed(s) }
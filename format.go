@@ -0,0 +1,277 @@
+package fuzzytime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// formatTokens are the layout elements understood by Format, in the
+// order they are tried. They follow the time package, using the
+// reference time "Mon Jan 2 15:04:05 MST 2006".
+var formatTokens = []string{
+	"January", "Jan", "Monday", "Mon", "MST",
+	"2006", "Z07:00", "-07:00", "-0700", "-07", ".000",
+	"01", "02", "_2", "03", "04", "05", "06", "15",
+	"1", "2", "3", "4", "5", "PM", "pm",
+}
+
+// Format returns the datetime formatted according to a layout, in the
+// style of time.Time.Format (eg "2 January 2006", "Jan 2", "15:04 MST").
+// Layout elements for unset fields are dropped along with an adjacent
+// separator, so "2 January 2006" gives "April 2014" if the day is
+// missing, and "January 2, 2006" gives "April 2014" rather than
+// "April , 2014". Weekdays are only output if the full date is set.
+// Timezones are written using the abbreviation they were parsed from
+// (eg "BST"), or else an abbreviation if the offset has an unambiguous
+// one, otherwise numerically.
+func (dt *DateTime) Format(layout string) string {
+	return dt.FormatLocale(layout, "en")
+}
+
+// FormatLocale is like Format, but uses month and weekday names from
+// the given language ("en", "es", "de", "fr" or "ru"). Unknown languages
+// fall back to English. Languages which inflect month names use the
+// genitive after a day (eg "10 апреля 2014", but "апрель 2014").
+func (dt *DateTime) FormatLocale(layout string, lang string) string {
+	names, ok := locales[lang]
+	if !ok {
+		names = locales["en"]
+	}
+
+	// split layout into literals and (rendered) tokens
+	lits := []string{""}
+	var rendered []string
+	var present []bool
+	afterDay := false // has a day been output?
+	for len(layout) > 0 {
+		tok := ""
+		for _, t := range formatTokens {
+			if strings.HasPrefix(layout, t) {
+				tok = t
+				break
+			}
+		}
+		if tok == "" {
+			lits[len(lits)-1] += layout[:1]
+			layout = layout[1:]
+			continue
+		}
+		out, ok := dt.formatToken(tok, names)
+		if tok == "January" && ok && afterDay && names.genitiveMonths[0] != "" {
+			out = names.genitiveMonths[dt.Month()-1]
+		}
+		if ok && (tok == "02" || tok == "_2" || tok == "2") {
+			afterDay = true
+		}
+		rendered = append(rendered, out)
+		present = append(present, ok)
+		lits = append(lits, "")
+		layout = layout[len(tok):]
+	}
+
+	// drop missing tokens, and tidy up the separators around them
+	for i := range rendered {
+		if present[i] {
+			continue
+		}
+		before, after := false, false
+		for j := range present {
+			if present[j] && j < i {
+				before = true
+			}
+			if present[j] && j > i {
+				after = true
+			}
+		}
+		switch {
+		case !before || !after:
+			if !before {
+				lits[i+1] = ""
+			}
+			if !after {
+				lits[i] = ""
+			}
+		default:
+			lits[i] = joinSeparators(lits[i], lits[i+1])
+			lits[i+1] = ""
+		}
+	}
+
+	out := lits[0]
+	for i, r := range rendered {
+		out += r + lits[i+1]
+	}
+	return out
+}
+
+// joinSeparators picks one separator to replace the two either side of a
+// dropped element. No separator beats any, and whitespace beats
+// punctuation (eg "3:04pm" => "3pm", "January 2, 2006" => "January 2006").
+func joinSeparators(a, b string) string {
+	switch {
+	case a == "" || b == "":
+		return ""
+	case strings.TrimSpace(a) == "":
+		return a
+	case strings.TrimSpace(b) == "":
+		return b
+	}
+	return b
+}
+
+// formatToken renders a single layout token. Returns false if the
+// fields it needs are unset.
+func (dt *DateTime) formatToken(tok string, names *localeNames) (string, bool) {
+	switch tok {
+	case "2006":
+		if dt.HasYear() {
+			return fmt.Sprintf("%04d", dt.Year()), true
+		}
+	case "06":
+		if dt.HasYear() {
+			return fmt.Sprintf("%02d", dt.Year()%100), true
+		}
+	case "January":
+		if dt.HasMonth() {
+			return names.months[dt.Month()-1], true
+		}
+	case "Jan":
+		if dt.HasMonth() {
+			return names.shortMonths[dt.Month()-1], true
+		}
+	case "01":
+		if dt.HasMonth() {
+			return fmt.Sprintf("%02d", dt.Month()), true
+		}
+	case "1":
+		if dt.HasMonth() {
+			return fmt.Sprintf("%d", dt.Month()), true
+		}
+	case "Monday", "Mon":
+		if dt.HasFullDate() {
+			wd := time.Date(dt.Year(), time.Month(dt.Month()), dt.Day(), 0, 0, 0, 0, time.UTC).Weekday()
+			if tok == "Monday" {
+				return names.days[wd], true
+			}
+			return names.shortDays[wd], true
+		}
+	case "02":
+		if dt.HasDay() {
+			return fmt.Sprintf("%02d", dt.Day()), true
+		}
+	case "_2":
+		if dt.HasDay() {
+			return fmt.Sprintf("%2d", dt.Day()), true
+		}
+	case "2":
+		if dt.HasDay() {
+			return fmt.Sprintf("%d", dt.Day()), true
+		}
+	case "15":
+		if dt.HasHour() {
+			return fmt.Sprintf("%02d", dt.Hour()), true
+		}
+	case "03", "3":
+		if dt.HasHour() {
+			h := dt.Hour() % 12
+			if h == 0 {
+				h = 12
+			}
+			if tok == "03" {
+				return fmt.Sprintf("%02d", h), true
+			}
+			return fmt.Sprintf("%d", h), true
+		}
+	case "PM", "pm":
+		if dt.HasHour() {
+			ampm := "AM"
+			if dt.Hour() >= 12 {
+				ampm = "PM"
+			}
+			if tok == "pm" {
+				ampm = strings.ToLower(ampm)
+			}
+			return ampm, true
+		}
+	case "04":
+		if dt.HasMinute() {
+			return fmt.Sprintf("%02d", dt.Minute()), true
+		}
+	case "4":
+		if dt.HasMinute() {
+			return fmt.Sprintf("%d", dt.Minute()), true
+		}
+	case "05":
+		if dt.HasSecond() {
+			return fmt.Sprintf("%02d", dt.Second()), true
+		}
+	case "5":
+		if dt.HasSecond() {
+			return fmt.Sprintf("%d", dt.Second()), true
+		}
+	case ".000":
+		if dt.HasFractional() {
+			return fmt.Sprintf(".%03d", dt.Fractional()), true
+		}
+	case "MST":
+		if dt.HasTZOffset() {
+			if name := dt.TZName(); name != "" {
+				return name, true
+			}
+			return tzAbbreviation(dt.TZOffset()), true
+		}
+	case "Z07:00", "-07:00", "-0700", "-07":
+		if dt.HasTZOffset() {
+			return formatOffset(tok, dt.TZOffset()), true
+		}
+	}
+	return "", false
+}
+
+// formatOffset writes a timezone offset in the style of one of the
+// numeric layout tokens
+func formatOffset(tok string, secs int) string {
+	if secs == 0 && tok == "Z07:00" {
+		return "Z"
+	}
+	sign := '+'
+	if secs < 0 {
+		sign = '-'
+		secs = -secs
+	}
+	hours, mins := secs/3600, (secs/60)%60
+	switch tok {
+	case "-0700":
+		return fmt.Sprintf("%c%02d%02d", sign, hours, mins)
+	case "-07":
+		return fmt.Sprintf("%c%02d", sign, hours)
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, hours, mins)
+}
+
+// tzAbbreviation returns the name of the timezone with the given offset,
+// if there is just one, or the offset as "+HH:MM" otherwise.
+// UTC is always "UTC".
+func tzAbbreviation(secs int) string {
+	if secs == 0 {
+		return "UTC"
+	}
+	name := ""
+	for _, infos := range tzTable {
+		for _, info := range infos {
+			offset, err := TZToOffset(info.Offset)
+			if err != nil || offset != secs {
+				continue
+			}
+			if name != "" && name != info.Name {
+				return formatOffset("-07:00", secs) // ambiguous
+			}
+			name = info.Name
+		}
+	}
+	if name == "" {
+		return formatOffset("-07:00", secs)
+	}
+	return name
+}
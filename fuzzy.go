@@ -1,7 +1,10 @@
 package fuzzytime
 
 import (
+	"bytes"
 	"errors"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -59,6 +62,30 @@ type Context struct {
 	// TZResolver returns the offset in seconds from UTC of the named zone (eg "EST").
 	// if the resolver can't decide which timezone it is, it will return an error.
 	TZResolver func(name string) (int, error)
+	// Eras is the set of non-Gregorian year numbering systems to accept
+	// (eg JapaneseEra|MinguoEra). Years written in these eras are converted
+	// to Gregorian, and Date.Era() reports which system was interpreted.
+	// Zero means Gregorian years only.
+	Eras Era
+	// Calendars is the set of non-Gregorian calendars to recognise (eg
+	// HijriCalendar|PersianCalendar). Dates in these calendars are converted
+	// to Gregorian, and Date.Original() returns the date as written.
+	Calendars Calendar
+	// FuzzyNames turns on approximate matching of month and weekday
+	// names (eg "Febuary", "Setpember") and correction of common OCR
+	// errors (eg "2O14", "0ct0ber"). Date.Penalty() reports how many
+	// corrections were made.
+	FuzzyNames bool
+	// Languages is a comma-separated list of language codes (eg "de,fr")
+	// whose month and weekday names should be recognised, on top of the
+	// built-in ones. With FuzzyNames set, empty means all known languages,
+	// and approximate matching is limited to the listed ones.
+	Languages string
+	// PairDistance is the furthest apart (in characters) a date and a time
+	// can be and still be taken as a single datetime. Zero means
+	// DefaultPairDistance. Dates and times in different sentences are
+	// never paired.
+	PairDistance int
 }
 
 // Extract tries to parse a Date and Time from a string
@@ -67,36 +94,268 @@ type Context struct {
 // If none found (or if there is an error), the returned
 // DateTime will be empty.
 func (ctx *Context) Extract(s string) (DateTime, []Span, error) {
-	// do time first to cope with cases where the time breaks up the date: "Thu Aug 25 10:46:55 GMT 2011"
-	ft, span1, err := ctx.ExtractTime(s)
+	m, err := ctx.extract(s)
 	if err != nil {
 		return DateTime{}, nil, err
 	}
+	return m.DateTime, m.Spans, nil
+}
+
+// maxTimeCandidates is the number of possible times Extract considers
+// pairing up with a date
+const maxTimeCandidates = 3
+
+// extract does the work for Extract and ExtractDetailed.
+// It does the time first to cope with cases where the time breaks up the
+// date ("Thu Aug 25 10:46:55 GMT 2011"), and masks it out before looking
+// for the date (hack for nasty case where an hour can look like a 2-digit
+// year). Rather than committing to the first time found, it tries a few
+// possible times (and no time at all), and picks the pairing which gives
+// the most complete result. If there's a tie, the date and time closest
+// together win. A time too far from the date (see PairDistance) is
+// dropped in favour of the nearest one which isn't.
+func (ctx *Context) extract(s string) (Match, error) {
+	norm, om := normalise(s)
+	times := ctx.timeCandidates(norm, maxTimeCandidates)
+	for i := range times {
+		times[i].span = om.span(times[i].span)
+		times[i].fields.remap(om)
+	}
+	// also try without a time
+	times = append(times, timeCandidate{})
+
+	var best Match
+	bestScore, bestGap := -1, 0
+	for i, tc := range times {
+		fd, span, fields, err := ctx.extractDateDetailed(maskSpan(s, tc.span))
+		if err != nil {
+			if i == 0 {
+				// say where the error was
+				return Match{Spans: []Span{span}}, err
+			}
+			continue // only the first choice gets to fail
+		}
+		if !fd.Empty() && !tc.t.Empty() {
+			if _, ok := ctx.near(s, coreDateSpan(span, &fields), coreTimeSpan(tc.span, &tc.fields)); !ok {
+				tc = timeCandidate{} // too far apart
+			}
+		}
+		score := pairScore(&fd, &tc.t)
+		if overlapsOther(span, times, i) {
+			// the date eats into one of the other times
+			score -= overlapPenalty
+		}
+		gap := 0
+		if !fd.Empty() && !tc.t.Empty() {
+			gap = spanGap(span, tc.span)
+		}
+		if score < bestScore || (score == bestScore && gap >= bestGap) {
+			continue
+		}
+		bestScore, bestGap = score, gap
+		best = Match{DateTime: DateTime{fd, tc.t}, Spans: tidySpans([]Span{tc.span, span}), Fields: fields, dateSpan: span, timeSpan: tc.span}
+		best.Fields.merge(&tc.fields)
+		if score == maxPairScore && gap <= 1 {
+			break // can't do any better
+		}
+	}
+	if !best.DateTime.Date.Empty() && best.DateTime.Time.Empty() && len(times) > 1 {
+		// the times we tried weren't near the date - look for one which is
+		ctx.attachNearestTime(s, &best)
+	}
+	best.Fields.setText(s)
+	return best, nil
+}
+
+// maxPairScore is the best score pairScore can give
+const maxPairScore = 3*2 + 1
+
+// overlapPenalty is taken off the score of a pairing where the date
+// overlaps a time which wasn't chosen. It's worth one date field, so a
+// date gets to claim digits which look like a time ("April 24th 14:30")
+// only if it's more complete for it.
+const overlapPenalty = 2
+
+// pairScore rates a pairing of a date and time by completeness. Each date
+// field counts double, so a more complete date is always preferred over
+// having a time.
+func pairScore(fd *Date, ft *Time) int {
+	score := 0
+	if fd.HasYear() {
+		score += 2
+	}
+	if fd.HasMonth() {
+		score += 2
+	}
+	if fd.HasDay() {
+		score += 2
+	}
 	if !ft.Empty() {
-		// snip the matched time out of the string
-		// (hack for nasty case where an hour can look like a 2-digit year)
-		s = s[:span1.Begin] + s[span1.End:]
+		score++
 	}
+	return score
+}
 
-	fd, span2, err := ctx.ExtractDate(s)
-	if err != nil {
-		return DateTime{}, nil, err
+// spanGap returns the number of bytes between two non-overlapping spans
+func spanGap(a, b Span) int {
+	if a.Begin > b.Begin {
+		a, b = b, a
 	}
+	if b.Begin < a.End {
+		return 0
+	}
+	return b.Begin - a.End
+}
 
-	if !fd.Empty() {
-		// fix up the second span to allow for the snipping
-		if span2.Begin >= span1.Begin {
-			span2.Begin += span1.End - span1.Begin
+// overlapsOther returns true if the span overlaps any of the time
+// candidates other than times[chosen] (or alternative readings of the
+// same text)
+func overlapsOther(sp Span, times []timeCandidate, chosen int) bool {
+	for _, tc := range times {
+		if overlaps(tc.span, times[chosen].span) {
+			continue
 		}
-		if span2.End >= span1.Begin {
-			span2.End += span1.End - span1.Begin
+		if overlaps(sp, tc.span) {
+			return true
 		}
 	}
+	return false
+}
+
+// overlaps returns true if two spans overlap
+func overlaps(a, b Span) bool {
+	return a.Begin < b.End && b.Begin < a.End
+}
 
-	// sort/merge spans
-	spans := tidySpans([]Span{span1, span2})
+// maskSpan blanks out part of a string with spaces, keeping the offsets
+// the same
+func maskSpan(s string, sp Span) string {
+	if sp.Begin == sp.End {
+		return s
+	}
+	return s[:sp.Begin] + strings.Repeat(" ", sp.End-sp.Begin) + s[sp.End:]
+}
+
+// Match is a datetime found by ExtractAll or ExtractDetailed, along with
+// the spans of text it was parsed from.
+type Match struct {
+	DateTime DateTime
+	Spans    []Span
+	// Fields holds the spans of the individual components
+	Fields Fields
+
+	// the date and time parts, as found (before tidying)
+	dateSpan, timeSpan Span
+}
+
+// ExtractAll finds all the datetimes in a string, in order of position.
+// Each match is found with Extract, then blanked out of the string before
+// looking for the next one. Longer strings are searched a window at a
+// time, so the time taken grows linearly with their length.
+// If an error occurs, the matches before it are returned along with the
+// error.
+func (ctx *Context) ExtractAll(s string) ([]Match, error) {
+	return searchAll([]byte(s), 0, len(s), windowMargin+ctx.pairDistance(), ctx.extract)
+}
+
+// ExtractAll finds all the datetimes in a string.
+// Equivalent to DefaultContext.ExtractAll()
+func ExtractAll(s string) ([]Match, error) { return DefaultContext.ExtractAll(s) }
+
+// Replace rewrites all the datetimes in a string, using fn to supply the
+// replacement text. fn is passed each datetime found, along with the span
+// it covers. A date and time written together ("10 March 2010 at 09:00")
+// are replaced as a whole, but if there's other text between them, fn is
+// called separately for the date and for the time, and the text between
+// is kept. Text outside the matches is copied byte-for-byte. For example,
+// to normalise dates to ISO8601:
+//
+//	out, err := ctx.Replace(s, func(dt DateTime, span Span) string {
+//	    return dt.ISOFormat()
+//	})
+//
+// If an error occurs, the matches found before it are still replaced, and
+// the error is returned along with the text.
+func (ctx *Context) Replace(s string, fn func(dt DateTime, span Span) string) (string, error) {
+	matches, err := ctx.ExtractAll(s)
+	var parts []replacement
+	for i := range matches {
+		parts = append(parts, matches[i].replacements(s)...)
+	}
+	sort.SliceStable(parts, func(i, j int) bool { return parts[i].span.Begin < parts[j].span.Begin })
+
+	var out bytes.Buffer
+	pos := 0
+	for _, part := range parts {
+		if part.span.Begin < pos {
+			continue // overlaps the previous match
+		}
+		out.WriteString(s[pos:part.span.Begin])
+		out.WriteString(fn(part.dt, part.span))
+		pos = part.span.End
+	}
+	out.WriteString(s[pos:])
+	return out.String(), err
+}
+
+// replacement is a part of the text to be replaced by Replace
+type replacement struct {
+	dt   DateTime
+	span Span
+}
+
+// joinRE matches the text allowed between a date and a time for Replace
+// to treat them as one
+var joinRE = regexp.MustCompile(`(?i)^[\s\p{Z},@T]*(?:at|on)?[\s\p{Z},]*$`)
+
+// replacements splits a match into the parts to be replaced. The time is
+// trimmed to the fields it was parsed from, as the time crackers can take
+// in a trailing character.
+func (m *Match) replacements(s string) []replacement {
+	ds, ts := m.dateSpan, m.timeSpan
+	if !m.DateTime.Time.Empty() {
+		ts = coreTimeSpan(ts, &m.Fields)
+	}
+	switch {
+	case m.DateTime.Time.Empty():
+		return []replacement{{m.DateTime, ds}}
+	case m.DateTime.Date.Empty():
+		return []replacement{{m.DateTime, ts}}
+	}
+	first, second := ds, ts
+	if first.Begin > second.Begin {
+		first, second = second, first
+	}
+	if overlaps(first, second) || joinRE.MatchString(s[first.End:second.Begin]) {
+		whole := Span{first.Begin, second.End}
+		if first.End > whole.End {
+			whole.End = first.End
+		}
+		return []replacement{{m.DateTime, whole}}
+	}
+	return []replacement{
+		{DateTime{Date: m.DateTime.Date}, ds},
+		{DateTime{Time: m.DateTime.Time}, ts},
+	}
+}
+
+// Replace rewrites all the datetimes in a string.
+// Equivalent to DefaultContext.Replace()
+func Replace(s string, fn func(dt DateTime, span Span) string) (string, error) {
+	return DefaultContext.Replace(s, fn)
+}
+
+// Span returns the span covering the whole match, from the start of its
+// first span to the end of its last.
+func (m *Match) Span() Span {
+	return Span{Begin: m.Spans[0].Begin, End: m.Spans[len(m.Spans)-1].End}
+}
 
-	return DateTime{fd, ft}, spans, nil
+// sortMatches puts matches in order of position
+func sortMatches(matches []Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Spans[0].Begin < matches[j].Spans[0].Begin
+	})
 }
 
 // DefaultTZResolver returns a TZResolver function which uses a list of country codes in
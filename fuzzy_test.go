@@ -1,7 +1,15 @@
 package fuzzytime
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestDateTimes(t *testing.T) {
@@ -81,6 +89,26 @@ func TestDateTimes(t *testing.T) {
 		{"Май 2008", "2008-05"},
 		{"10 апреля 2014", "2014-04-10"},
 
+		// Chinese, Japanese, Korean
+		{"2014年4月10日 15時30分", "2014-04-10T15:30"},
+		{"2014年4月10日（木）", "2014-04-10"},
+		{"2014년 4월 10일 오후 3시", "2014-04-10T15"},
+		{"2014년 4월 10일 오전 9시 5분", "2014-04-10T09:05"},
+		{"２０１４年４月１０日 午前１１時５分", "2014-04-10T11:05"},
+		{"2014年4月10日 午後3:30", "2014-04-10T15:30"},
+		{"2014年4月", "2014-04"},
+		{"下午3点20分", "T15:20"},
+		{"下午3点", "T15"},
+		{"3点30分", "T03:30"},
+		{"上午10點", "T10"},
+
+		// non-ascii digits and separators
+		{"٢٠١٤-٠٤-١٠", "2014-04-10"},
+		{"२०१४-०४-१०", "2014-04-10"},
+		{"10 April २०१४", "2014-04-10"},
+		{"２０１４／４／１０ １５：３０", "2014-04-10T15:30"},
+		{"2014‐04‐10 15∶30", "2014-04-10T15:30"},
+
 		// *****
 		// Ones that should fail
 		// *****
@@ -94,6 +122,10 @@ func TestDateTimes(t *testing.T) {
 		// time or date?
 		{"10.12", ""},
 
+		// "点" is also "point"
+		{"room 3点", ""},
+		{"得了3點", ""},
+
 		// invalid values:
 		{"25:10:01GMT", ""},
 		{"2000-15-02", ""},
@@ -138,6 +170,7 @@ func TestPartial(t *testing.T) {
 		{"April 24th", "????-04-24 ??:??:??"},
 		{"May 2", "????-05-02 ??:??:??"},
 		{"8:50am Thu April 24th", "????-04-24 08:50:??"},
+		{"4月24日 午後3時", "????-04-24 15:??:??"},
 	}
 	for _, dat := range testData {
 		dt, _, err := Extract(dat.in)
@@ -218,6 +251,902 @@ func TestAmbiguous(t *testing.T) {
 	}
 }
 
+func TestEras(t *testing.T) {
+	ctx := WesternContext
+	ctx.Eras = JapaneseEra | MinguoEra | BuddhistEra
+
+	testData := []struct {
+		in       string
+		expected string
+		era      Era
+	}{
+		{"平成26年4月10日", "2014-04-10", JapaneseEra},
+		{"令和元年5月1日 10時", "2019-05-01T10", JapaneseEra},
+		{"昭和64年1月7日", "1989-01-07", JapaneseEra},
+		{"民國103年4月10日", "2014-04-10", MinguoEra},
+		{"中華民國103年4月", "2014-04", MinguoEra},
+		{"103年4月10日", "2014-04-10", MinguoEra},
+		{"10 เมษายน 2557", "2014-04-10", BuddhistEra},
+		{"10 เม.ย. พ.ศ. 2557", "2014-04-10", BuddhistEra},
+		{"10 เมษายน ค.ศ. 2014", "2014-04-10", 0},
+		{"10/04/2557", "2014-04-10", BuddhistEra},
+		{"2014年4月10日", "2014-04-10", 0},
+		{"10 April 2014", "2014-04-10", 0},
+	}
+	for _, dat := range testData {
+		dt, _, err := ctx.Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", dat.in, err)
+		}
+		got := dt.ISOFormat()
+		if got != dat.expected {
+			t.Errorf("Extract(%s): expected %s, but got %s", dat.in, dat.expected, got)
+		}
+		if dt.Era() != dat.era {
+			t.Errorf("Extract(%s): expected era %s, but got %s", dat.in, dat.era, dt.Era())
+		}
+	}
+
+	// eras not enabled - should only pick up the month and day
+	dt, _, _ := Extract("平成26年4月10日")
+	if got := dt.String(); got != "????-04-10 ??:??:??" {
+		t.Errorf("Extract(平成26年4月10日) without eras: got %s", got)
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	testData := []struct {
+		in          string
+		first, last string
+	}{
+		{"spring 2009", "2009-03-01", "2009-05-31"},
+		{"Winter 2009", "2009-12-01", "2010-02-28"},
+		{"Q3 2011", "2011-07-01", "2011-09-30"},
+		{"2011-Q4", "2011-10-01", "2011-12-31"},
+		{"H1 2015", "2015-01-01", "2015-06-30"},
+		{"second half of 2012", "2012-07-01", "2012-12-31"},
+		{"mid-March 2010", "2010-03-11", "2010-03-21"},
+		{"early May 2011", "2011-05-01", "2011-05-10"},
+		{"late 2010", "2010-09-01", "2010-12-31"},
+		{"the 1980s", "1980-01-01", "1989-12-31"},
+		{"late 1990s", "1997-01-01", "1999-12-31"},
+		{"the '60s", "1960-01-01", "1969-12-31"},
+		{"the 1800s", "1800-01-01", "1899-12-31"},
+		{"early 20th century", "1900-01-01", "1932-12-31"},
+		{"circa 1850", "1849-01-01", "1851-12-31"},
+		{"c. 1850", "1849-01-01", "1851-12-31"},
+		{"May 2011", "2011-05-01", "2011-05-31"},
+		{"2012-02-29", "2012-02-29", "2012-02-29"},
+	}
+	for _, dat := range testData {
+		d, _, err := ExtractDate(dat.in)
+		if err != nil {
+			t.Errorf("ExtractDate(%s) failed: %s", dat.in, err)
+			continue
+		}
+		first, last, err := d.Range()
+		if err != nil {
+			t.Errorf("ExtractDate(%s).Range() failed: %s", dat.in, err)
+			continue
+		}
+		if first.ISOFormat() != dat.first || last.ISOFormat() != dat.last {
+			t.Errorf("ExtractDate(%s): expected %s..%s, but got %s..%s", dat.in, dat.first, dat.last, first.ISOFormat(), last.ISOFormat())
+		}
+	}
+
+	if _, _, err := NewDate(0, 3, 10).Range(); err == nil {
+		t.Errorf("Range() without a year: expected an error")
+	}
+
+	// negative (EDTF) years
+	var d Date
+	d.SetYear(-5)
+	d.SetPeriod(Q3)
+	if first, last, err := d.Range(); err != nil || first.ISOFormat() != "-0005-07-01" || last.ISOFormat() != "-0005-09-30" {
+		t.Errorf("Range() of Q3 -0005: got %s..%s (%v)", first.ISOFormat(), last.ISOFormat(), err)
+	}
+
+	// short periods shouldn't be picked out of other numbers
+	for _, in := range []string{"call 555 1234 h1 2015", "ah1 2015", "éq3 2011"} {
+		if d, _, _ := ExtractDate(in); !d.Empty() {
+			t.Errorf("ExtractDate(%s): expected nothing, got %s", in, d.ISOFormat())
+		}
+	}
+}
+
+func TestEDTF(t *testing.T) {
+	testData := []struct {
+		in       string
+		expected string
+	}{
+		{"2004-06-11", "2004-06-11"},
+		{"2004-06~", "2004-06~"},
+		{"2004?-06", "2004?-06"},
+		{"2004-06-11%", "2004-06-11%"},
+		{"2004-?06-11", "2004-?06-11"},
+		{"?2004-06-~11", "?2004-06-~11"},
+		{"2004-XX-11", "2004-XX-11"},
+		{"XXXX-04-10", "XXXX-04-10"},
+		{"201X", "201X"},
+		{"19XX", "19XX"},
+		{"2001-21", "2001-21"},
+		{"2001-25", "2001-21"},
+		{"2011-35", "2011-35"},
+		{"-0100", "-0100"},
+		{"Y170000002", "Y170000002"},
+		{"1985-04-12T23:20:30", "1985-04-12T23:20:30"},
+		{"1985-04-12T23:20:30Z", "1985-04-12T23:20:30Z"},
+		{"1985-04-12T23:20:30-04", "1985-04-12T23:20:30-04:00"},
+		{"1984/2004", "1984/2004"},
+		{"1984-06/2004-08~", "1984-06/2004-08~"},
+		{"1985/..", "1985/.."},
+		{"/2004", "/2004"},
+		{"[1667,1668,1670..1672]", "[1667,1668,1670..1672]"},
+		{"[..1760-12-03]", "[..1760-12-03]"},
+		{"{1960, 1961-12}", "{1960,1961-12}"},
+	}
+	for _, dat := range testData {
+		e, err := ParseEDTF(dat.in)
+		if err != nil {
+			t.Errorf("ParseEDTF(%s) failed: %s", dat.in, err)
+			continue
+		}
+		if got := e.String(); got != dat.expected {
+			t.Errorf("ParseEDTF(%s): expected %s, but got %s", dat.in, dat.expected, got)
+		}
+	}
+
+	bad := []string{"", "2004-13", "2004-06-32", "1X99", "2004-21-01", "2004-06T10:00", "1985-04-12T23:20", "1985-04-12T23:20Z", "1984/2004/2010", "[1667,1668", "0000"}
+	for _, in := range bad {
+		if _, err := ParseEDTF(in); err == nil {
+			t.Errorf("ParseEDTF(%s): expected an error", in)
+		}
+	}
+
+	// output from extracted dates
+	extracted := []struct {
+		in       string
+		expected string
+	}{
+		{"10 April 2014 10:30am", "2014-04-10"},
+		{"10 April 2014 10:30:15", "2014-04-10T10:30:15"},
+		{"April 10th", "XXXX-04-10"},
+		{"circa 1850", "1850~"},
+		{"the 1980s", "198X"},
+		{"Q3 2011", "2011-35"},
+		{"10:30am", ""},
+	}
+	for _, dat := range extracted {
+		dt, _, err := Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", dat.in, err)
+			continue
+		}
+		if got := dt.EDTFFormat(); got != dat.expected {
+			t.Errorf("Extract(%s).EDTFFormat(): expected %s, but got %s", dat.in, dat.expected, got)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	testData := []struct {
+		in       string
+		expected string
+	}{
+		{"Tuesday 16 December 2008 10:30:05.123 GMT", "2008-12-16T10:30:05.123Z"},
+		{"2010-04-02T12:35:44+01:00", "2010-04-02T12:35:44+01:00"},
+		{"May 2011", "2011-05"},
+		{"April 24th", "????-04-24 ??:??:??"},
+		{"14:51", "T14:51"},
+		{"April 24th 14:30 PST", "????-04-24 14:30:??-08:00"},
+		{"", ""},
+	}
+	for _, dat := range testData {
+		dt, _, err := Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", dat.in, err)
+			continue
+		}
+		text, err := dt.MarshalText()
+		if err != nil {
+			t.Errorf("MarshalText(%s) failed: %s", dat.in, err)
+			continue
+		}
+		if string(text) != dat.expected {
+			t.Errorf("MarshalText(%s): expected %s, but got %s", dat.in, dat.expected, text)
+		}
+
+		// round trip, via JSON and sql
+		var fromJSON, fromSQL DateTime
+		data, err := json.Marshal(dt)
+		if err == nil {
+			err = json.Unmarshal(data, &fromJSON)
+		}
+		if err != nil {
+			t.Errorf("JSON round trip (%s) failed: %s", dat.in, err)
+		} else if !fromJSON.Equals(&dt) {
+			t.Errorf("JSON round trip (%s): expected %s, but got %s", dat.in, dt.String(), fromJSON.String())
+		}
+		v, err := dt.Value()
+		if err == nil {
+			err = fromSQL.Scan(v)
+		}
+		if err != nil {
+			t.Errorf("sql round trip (%s) failed: %s", dat.in, err)
+		} else if !fromSQL.Equals(&dt) {
+			t.Errorf("sql round trip (%s): expected %s, but got %s", dat.in, dt.String(), fromSQL.String())
+		}
+	}
+
+	// Date and Time on their own
+	d := Date{}
+	d.SetMonth(4)
+	d.SetDay(24)
+	var d2 Date
+	if err := d2.UnmarshalText([]byte("????-04-24")); err != nil || !d2.Equals(&d) {
+		t.Errorf("Date.UnmarshalText(????-04-24): got %s (%v)", d2.String(), err)
+	}
+	var t2 Time
+	if err := json.Unmarshal([]byte(`"14:??:05"`), &t2); err != nil || t2.String() != "14:??:05" {
+		t.Errorf("Time.UnmarshalJSON(14:??:05): got %s (%v)", t2.String(), err)
+	}
+
+	// periods, qualifiers, eras etc survive the round trip too
+	ctx := DefaultContext
+	ctx.Eras = JapaneseEra
+	ctx.Calendars = HijriCalendar
+	bc, _ := ParseEDTF("-0005-03")
+	testExtras := []struct {
+		dt       DateTime
+		expected string
+	}{
+		{bc.DateTime, "-0005-03"},
+		{mustExtract(t, &ctx, "Q3 2011"), "2011-35"},
+		{mustExtract(t, &ctx, "early 2010"), "2010[_qualifier=early]"},
+		{mustExtract(t, &ctx, "circa 1850"), "1850~"},
+		{mustExtract(t, &ctx, "the late 1990s"), "199X[_qualifier=late]"},
+		{mustExtract(t, &ctx, "平成26年4月10日 10時"), "2014-04-10T10[_era=japanese]"},
+		{mustExtract(t, &ctx, "Ramadan 1, 1445"), "2024-03-11[_orig=hijri:1445-09-01]"},
+	}
+	for _, dat := range testExtras {
+		text, _ := dat.dt.MarshalText()
+		if string(text) != dat.expected {
+			t.Errorf("MarshalText(%s): expected %s, but got %s", dat.dt.String(), dat.expected, text)
+		}
+		var back DateTime
+		if err := back.UnmarshalText(text); err != nil {
+			t.Errorf("UnmarshalText(%s) failed: %s", text, err)
+		} else if !reflect.DeepEqual(back, dat.dt) {
+			t.Errorf("UnmarshalText(%s): expected %+v, but got %+v", text, dat.dt, back)
+		}
+		var d Date
+		if err := d.UnmarshalText([]byte(dat.dt.Date.text())); err != nil || !reflect.DeepEqual(d, dat.dt.Date) {
+			t.Errorf("Date.UnmarshalText(%s): got %+v (%v)", dat.dt.Date.text(), d, err)
+		}
+	}
+
+	// timezone names are kept in memory, but not written out
+	bst := mustExtract(t, &WesternContext, "10 April 2014 15:30 BST")
+	if bst.TZName() != "BST" {
+		t.Errorf("TZName(BST): got %q", bst.TZName())
+	}
+	if text, _ := bst.MarshalText(); string(text) != "2014-04-10T15:30+01:00" {
+		t.Errorf("MarshalText(BST): got %s", text)
+	}
+
+	for _, bad := range []string{"2010-13", "yesterday", "2010-04-02T", "25:00", "2010[_era=roman]", "2010[_foo=bar]", "2010[_tz=BST]"} {
+		var dt DateTime
+		if err := dt.UnmarshalText([]byte(bad)); err == nil {
+			t.Errorf("UnmarshalText(%s): expected an error", bad)
+		}
+	}
+}
+
+// mustExtract extracts a datetime which is expected to be there
+func mustExtract(t *testing.T, ctx *Context, s string) DateTime {
+	dt, _, err := ctx.Extract(s)
+	if err != nil || dt.Empty() {
+		t.Fatalf("Extract(%s) failed: %v", s, err)
+	}
+	return dt
+}
+
+func TestParseFormatted(t *testing.T) {
+	inputs := []string{
+		"Tuesday 16 December 2008 10:30:05.123 GMT",
+		"2010-04-02T12:35:44+05:30",
+		"May 2011",
+		"April 24th",
+		"14:51",
+		"April 24th 14:30 PST",
+		"",
+	}
+	for _, in := range inputs {
+		dt, _, err := Extract(in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", in, err)
+			continue
+		}
+		s := dt.String()
+		got, err := ParseString(s)
+		if err != nil {
+			t.Errorf("ParseString(%s) failed: %s", s, err)
+		} else if !got.Equals(&dt) {
+			t.Errorf("ParseString(%s): got %s", s, got.String())
+		}
+
+		if !dt.Date.isoLossless() || !dt.Time.isoLossless() {
+			continue
+		}
+		iso := dt.ISOFormat()
+		got, err = ParseISO(iso)
+		if err != nil {
+			t.Errorf("ParseISO(%s) failed: %s", iso, err)
+		} else if !got.Equals(&dt) {
+			t.Errorf("ParseISO(%s): got %s", iso, got.String())
+		}
+	}
+
+	badString := []string{"2010-04-24", "2010-4-24 ??:??:??", "????-04-24 25:??:??", "????-13-?? ??:??:??", "????-04-24 ??:??:??+5"}
+	for _, in := range badString {
+		if _, err := ParseString(in); err == nil {
+			t.Errorf("ParseString(%s): expected an error", in)
+		}
+	}
+	badISO := []string{"2010-04-24T", "2010/04/24", "T", "2010-04-24 10:00", "T10:61", "2010-04-24T10:00+0100"}
+	for _, in := range badISO {
+		if _, err := ParseISO(in); err == nil {
+			t.Errorf("ParseISO(%s): expected an error", in)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testData := []struct {
+		in       string
+		layout   string
+		expected string
+	}{
+		{"2014-04-10T15:30:00+01:00", "2 January 2006", "10 April 2014"},
+		{"April 2014", "2 January 2006", "April 2014"},
+		{"April 10th", "2 January 2006", "10 April"},
+		{"April 10th", "Jan 2", "Apr 10"},
+		{"April 2014", "Jan 2", "Apr"},
+		{"April 2014", "January 2, 2006", "April 2014"},
+		{"2014-04-10", "Mon, 2 Jan 2006", "Thu, 10 Apr 2014"},
+		{"April 10th", "Mon, 2 Jan 2006", "10 Apr"},
+		{"2014-04-10", "2006-01-02 15:04", "2014-04-10"},
+		{"15:30", "15:04 MST", "15:30"},
+		{"15:30 NPT", "15:04 MST", "15:30 NPT"},
+		{"15:30 CET", "15:04 MST", "15:30 CET"},
+		{"15:30 BST", "15:04 MST", "15:30 BST"},
+		{"15:30 +01:00", "15:04 MST", "15:30 +01:00"},
+		{"15:30 GMT", "3:04pm MST", "3:30pm GMT"},
+		{"15:30Z", "3:04pm MST", "3:30pm UTC"},
+		{"2014-04-10T15:30:00.250-05:00", "02/01/06 15:04:05.000 -0700", "10/04/14 15:30:00.250 -0500"},
+		{"", "2 January 2006", ""},
+	}
+	for _, dat := range testData {
+		dt, _, err := Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", dat.in, err)
+			continue
+		}
+		if got := dt.Format(dat.layout); got != dat.expected {
+			t.Errorf("Extract(%s).Format(%q): expected %q, but got %q", dat.in, dat.layout, dat.expected, got)
+		}
+	}
+
+	dt, _, _ := Extract("2014-04-10")
+	if got := dt.FormatLocale("Monday 2 January 2006", "fr"); got != "jeudi 10 avril 2014" {
+		t.Errorf("FormatLocale(fr): got %q", got)
+	}
+	if got := dt.FormatLocale("2 January 2006", "ru"); got != "10 апреля 2014" {
+		t.Errorf("FormatLocale(ru): got %q", got)
+	}
+	if got := dt.FormatLocale("January 2006", "ru"); got != "апрель 2014" {
+		t.Errorf("FormatLocale(ru) with no day: got %q", got)
+	}
+	if got := dt.FormatLocale("Mon 2 Jan", "xx"); got != "Thu 10 Apr" {
+		t.Errorf("FormatLocale(xx): got %q", got)
+	}
+
+	dt = DateTime{}
+	dt.SetHour(15)
+	if got := dt.Format("3:04pm"); got != "3pm" {
+		t.Errorf("Format(3:04pm) with no minutes: got %q", got)
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	ref := time.Date(2014, 5, 14, 16, 0, 0, 0, time.UTC)
+	testData := []struct {
+		in       string
+		lang     string
+		expected string
+	}{
+		{"2014-05-14T15:59:40Z", "en", "just now"},
+		{"2014-05-14T15:30:00Z", "en", "30 minutes ago"},
+		{"2014-05-14T13:00:00Z", "en", "3 hours ago"},
+		{"2014-05-14T18:00:00Z", "en", "in 2 hours"},
+		{"2014-05-13T15:30:00Z", "en", "yesterday at 15:30"},
+		{"2014-05-15T09:00:00Z", "en", "tomorrow at 09:00"},
+		{"2014-05-14", "en", "today"},
+		{"2014-05-11", "en", "3 days ago"},
+		{"2014-06-04", "en", "in 3 weeks"},
+		{"2014-02-10", "en", "3 months ago"},
+		{"2012-05-01", "en", "2 years ago"},
+		{"April 2014", "en", "last April"},
+		{"May 2014", "en", "this month"},
+		{"August 2014", "en", "next August"},
+		{"April 2010", "en", "April 2010"},
+		{"April 10th", "en", "10 April"},
+		{"15:30", "en", "15:30"},
+		{"2014-05-13T15:30:00Z", "fr", "hier à 15:30"},
+		{"2014-05-11", "de", "vor 3 Tagen"},
+		{"April 2014", "es", "el pasado abril"},
+		{"2014-05-11", "xx", "3 days ago"},
+	}
+	for _, dat := range testData {
+		dt, _, err := Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", dat.in, err)
+			continue
+		}
+		if got := dt.HumanizeLocale(ref, dat.lang); got != dat.expected {
+			t.Errorf("Extract(%s).HumanizeLocale(%s): expected %q, but got %q", dat.in, dat.lang, dat.expected, got)
+		}
+	}
+
+	// year only
+	dt := DateTime{Date: *NewDate(2013, 0, 0)}
+	if got := dt.Humanize(ref); got != "last year" {
+		t.Errorf("Humanize(2013): got %q", got)
+	}
+	dt = DateTime{Date: *NewDate(2010, 0, 0)}
+	if got := dt.Humanize(ref); got != "4 years ago" {
+		t.Errorf("Humanize(2010): got %q", got)
+	}
+
+	// times in other zones are compared in the reference time's zone
+	late := time.Date(2014, 5, 14, 23, 30, 0, 0, time.UTC)
+	for _, in := range []string{"2014-05-15T01:00+02:00", "2014-05-14T23:00Z"} {
+		dt, _, _ := Extract(in)
+		if got := dt.Humanize(late); got != "30 minutes ago" {
+			t.Errorf("Extract(%s).Humanize(): got %q", in, got)
+		}
+	}
+	dt, _, _ = Extract("2014-05-15T02:30+02:00")
+	if got := dt.Humanize(time.Date(2014, 5, 16, 12, 0, 0, 0, time.UTC)); got != "yesterday at 00:30" {
+		t.Errorf("Extract(2014-05-15T02:30+02:00).Humanize(): got %q", got)
+	}
+
+	// no minutes, so no minute-level deltas
+	ref = time.Date(2014, 5, 14, 15, 20, 0, 0, time.UTC)
+	for hour, expected := range map[int]string{15: "today", 13: "2 hours ago", 18: "in 3 hours"} {
+		dt := DateTime{Date: *NewDate(2014, 5, 14)}
+		dt.Time.SetHour(hour)
+		if got := dt.Humanize(ref); got != expected {
+			t.Errorf("Humanize(2014-05-14T%02d): expected %q, got %q", hour, expected, got)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	testData := []struct {
+		a, b     string
+		expected Ordering
+	}{
+		{"2010-05-03T12:00:00Z", "2010-05-03T12:00:01Z", OrderBefore},
+		{"2010-05", "2010-06-01T15:00Z", OrderBefore},
+		{"2011-01-01", "2010", OrderAfter},
+		{"2010", "2010-05", OrderOverlap},
+		{"2010-05-03T12:00Z", "2010-05-03T14:00+02:00", OrderOverlap},
+		{"2010-05-03T12:00Z", "2010-05-03T13:00+02:00", OrderAfter},
+		{"2010-05-03", "2010-05-04", OrderBefore},
+		// one side has no zone, so could be up to 14 hours either way
+		{"2010-05-03", "2010-05-04T10:00Z", OrderOverlap},
+		{"2010-05-03", "2010-05-04T15:00Z", OrderBefore},
+		{"????-05-03 ??:??:??", "2010-05-04", OrderUnknown},
+	}
+	for _, dat := range testData {
+		a, _ := parseDateTimeText(dat.a)
+		b, _ := parseDateTimeText(dat.b)
+		if got := a.Compare(&b); got != dat.expected {
+			t.Errorf("Compare(%s, %s): expected %s, but got %s", dat.a, dat.b, dat.expected, got)
+		}
+	}
+
+	a, _ := ParseISO("2010-05")
+	b, _ := ParseISO("2010-06-01")
+	if !a.Before(&b) || a.After(&b) || !b.After(&a) {
+		t.Errorf("Before/After(2010-05, 2010-06-01) failed")
+	}
+
+	var timeline []DateTime
+	for _, in := range []string{"2010-05-03T12:00Z", "????-05-03 ??:??:??", "2010-05", "2009-12-25", "2010"} {
+		dt, _ := parseDateTimeText(in)
+		timeline = append(timeline, dt)
+	}
+	SortDateTimes(timeline)
+	var got []string
+	for _, dt := range timeline {
+		got = append(got, dt.ISOFormat())
+	}
+	expected := []string{"2009-12-25", "2010", "2010-05", "2010-05-03T12:00Z", ""}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("SortDateTimes: expected %v, but got %v", expected, got)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	testData := []struct {
+		in               string
+		earliest, latest string
+	}{
+		{"2010-05T00+01:00", "2010-04-30T23:00:00Z", "2010-05-31T22:59:59.999999999Z"},
+		{"2010-05-03T12:30Z", "2010-05-03T12:30:00Z", "2010-05-03T12:30:59.999999999Z"},
+		{"2010-05-03T12:30:15.250-05:00", "2010-05-03T17:30:15.25Z", "2010-05-03T17:30:15.250999999Z"},
+		{"2010-05", "2010-04-30T10:00:00Z", "2010-06-01T13:59:59.999999999Z"},
+		{"2010", "2009-12-31T10:00:00Z", "2011-01-01T13:59:59.999999999Z"},
+	}
+	for _, dat := range testData {
+		dt, err := ParseISO(dat.in)
+		if err != nil {
+			t.Errorf("ParseISO(%s) failed: %s", dat.in, err)
+			continue
+		}
+		earliest, latest, err := dt.Bounds()
+		if err != nil {
+			t.Errorf("Bounds(%s) failed: %s", dat.in, err)
+			continue
+		}
+		e, l := earliest.Format(time.RFC3339Nano), latest.Format(time.RFC3339Nano)
+		if e != dat.earliest || l != dat.latest {
+			t.Errorf("Bounds(%s): expected %s..%s, but got %s..%s", dat.in, dat.earliest, dat.latest, e, l)
+		}
+	}
+
+	dt, _ := ParseString("????-05-03 12:00:??")
+	if _, _, err := dt.Bounds(); err == nil {
+		t.Errorf("Bounds() without a year: expected an error")
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	dateData := []struct {
+		in                  string
+		years, months, days int
+		expected            string // "" for error
+	}{
+		{"2010-03-03", 0, 0, 90, "2010-06-01"},
+		{"2010-05", 0, 1, 0, "2010-06"},
+		{"2010-11", 0, 3, 0, "2011-02"},
+		{"2010", -2, 0, 0, "2008"},
+		{"2010-01-31", 0, 1, 0, "2010-03-03"},
+		{"2010", 0, 1, 0, ""},
+		{"2010-05", 0, 0, 1, ""},
+		{"????-05-03 ??:??:??", 1, 0, 0, ""},
+		{"????-05-03 ??:??:??", 0, 0, 0, "????-05-03"},
+	}
+	for _, dat := range dateData {
+		dt, _ := parseDateTimeText(dat.in)
+		got, err := dt.AddDate(dat.years, dat.months, dat.days)
+		if dat.expected == "" {
+			if err == nil {
+				t.Errorf("AddDate(%s, %d, %d, %d): expected an error", dat.in, dat.years, dat.months, dat.days)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("AddDate(%s, %d, %d, %d) failed: %s", dat.in, dat.years, dat.months, dat.days, err)
+		} else if got.Date.text() != dat.expected {
+			t.Errorf("AddDate(%s, %d, %d, %d): expected %s, but got %s", dat.in, dat.years, dat.months, dat.days, dat.expected, got.Date.text())
+		}
+	}
+
+	durData := []struct {
+		in       string
+		dur      time.Duration
+		expected string // "" for error
+	}{
+		{"2010-12-31T23:30Z", time.Hour, "2011-01-01T00:30Z"},
+		{"2010-03-01T01:00:00", -2 * time.Hour, "2010-02-28T23:00:00"},
+		{"2010-03-01T01", 3 * time.Hour, "2010-03-01T04"},
+		{"2010-03-01T01", 30 * time.Minute, ""},
+		{"T23:15", time.Hour, "T00:15"},
+		{"2010-03T23:15", time.Hour, ""},
+		{"2010-03T20:15", time.Hour, "2010-03T21:15"},
+		{"2010-03-01T10:00:00.500", 750 * time.Millisecond, "2010-03-01T10:00:01.250"},
+		{"2010-03-01", time.Hour, ""},
+	}
+	for _, dat := range durData {
+		dt, _ := ParseISO(dat.in)
+		got, err := dt.AddDuration(dat.dur)
+		if dat.expected == "" {
+			if err == nil {
+				t.Errorf("AddDuration(%s, %s): expected an error", dat.in, dat.dur)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("AddDuration(%s, %s) failed: %s", dat.in, dat.dur, err)
+		} else if got.ISOFormat() != dat.expected {
+			t.Errorf("AddDuration(%s, %s): expected %s, but got %s", dat.in, dat.dur, dat.expected, got.ISOFormat())
+		}
+	}
+
+	subData := []struct {
+		a, b     string
+		expected time.Duration
+		ok       bool
+	}{
+		{"2010-03-01T10:00Z", "2010-02-28T09:30Z", 24*time.Hour + 30*time.Minute, true},
+		{"2010-03-01T10:00+02:00", "2010-03-01T10:00Z", -2 * time.Hour, true},
+		{"2010-03-01T10:00", "2010-03-01T09:00:00", 0, false},
+		{"2010-03-01T10:00Z", "2010-03-01T09:00", 0, false},
+		{"2010-03T10:00", "2010-03-01T09:00", 0, false},
+		{"2010-03-03", "2010-01-01", 61 * 24 * time.Hour, true},
+		{"2010-03-03", "2010-01-01T10:00", 0, false},
+	}
+	for _, dat := range subData {
+		a, _ := ParseISO(dat.a)
+		b, _ := ParseISO(dat.b)
+		got, err := a.Sub(&b)
+		if !dat.ok {
+			if err == nil {
+				t.Errorf("Sub(%s, %s): expected an error", dat.a, dat.b)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Sub(%s, %s) failed: %s", dat.a, dat.b, err)
+		} else if got != dat.expected {
+			t.Errorf("Sub(%s, %s): expected %s, but got %s", dat.a, dat.b, dat.expected, got)
+		}
+	}
+
+	a, b := NewDate(2010, 3, 1), NewDate(2010, 2, 1)
+	if days, err := a.Sub(b); err != nil || days != 28 {
+		t.Errorf("Date.Sub(2010-03-01, 2010-02-01): got %d (%v)", days, err)
+	}
+}
+
+func TestIn(t *testing.T) {
+	testData := []struct {
+		in       string
+		offset   int
+		expected string // "" for error
+	}{
+		{"2014-04-16T01:00+12:00", 0, "2014-04-15T13:00Z"},
+		{"2014-01-01T01:00+12:00", 0, "2013-12-31T13:00Z"},
+		{"2014-02-28T22:30:15-05:00", 0, "2014-03-01T03:30:15Z"},
+		{"2014-04-16T01:00Z", 5*3600 + 1800, "2014-04-16T06:30+05:30"},
+		{"2014-04-16T01+12:00", 0, "2014-04-15T13Z"},
+		{"2014-04-16T01+05:30", 0, ""},
+		{"2014-04T01:00+12:00", 0, ""},
+		{"2014-04T20:00+12:00", 0, "2014-04T08:00Z"},
+		{"T01:00+12:00", 0, "T13:00Z"},
+		{"2014-04-16T01:00", 0, ""},
+	}
+	for _, dat := range testData {
+		dt, _ := ParseISO(dat.in)
+		got, err := dt.In(dat.offset)
+		if dat.expected == "" {
+			if err == nil {
+				t.Errorf("In(%s, %d): expected an error", dat.in, dat.offset)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("In(%s, %d) failed: %s", dat.in, dat.offset, err)
+		} else if got.ISOFormat() != dat.expected {
+			t.Errorf("In(%s, %d): expected %s, but got %s", dat.in, dat.offset, dat.expected, got.ISOFormat())
+		}
+	}
+
+	dt, _ := ParseISO("2014-04-16T01:00+12:00")
+	if got, err := dt.UTC(); err != nil || got.ISOFormat() != "2014-04-15T13:00Z" {
+		t.Errorf("UTC(2014-04-16T01:00+12:00): got %s (%v)", got.ISOFormat(), err)
+	}
+}
+
+func TestExtractPath(t *testing.T) {
+	testData := []struct {
+		in       string
+		expected string
+		span     Span
+	}{
+		{"/2014/04/10/some-slug", "2014-04-10", Span{1, 11}},
+		{"http://example.com/2014/apr/10/story", "2014-04-10", Span{19, 30}},
+		{"/sport/2014/04/", "2014-04", Span{7, 14}},
+		{"/news/20140410-slug.html", "2014-04-10", Span{6, 14}},
+		{"IMG_20140410_153000.jpg", "2014-04-10T15:30:00", Span{4, 19}},
+		{"backup-2014-04-10T1530.tar.gz", "2014-04-10T15:30", Span{7, 22}},
+		{"report_2014_04_10.pdf", "2014-04-10", Span{7, 17}},
+		{"/story/12345678/2014/02/28/", "2014-02-28", Span{16, 26}},
+		{"/article/20140231-slug", "", Span{}},
+		{"/id/99991231/", "", Span{}},
+		{"/2014-04_10/", "", Span{}},
+		{"/about/", "", Span{}},
+	}
+	for _, dat := range testData {
+		dt, spans, err := ExtractPath(dat.in)
+		if err != nil {
+			t.Errorf("ExtractPath(%s) failed: %s", dat.in, err)
+			continue
+		}
+		if got := dt.ISOFormat(); got != dat.expected {
+			t.Errorf("ExtractPath(%s): expected %q, but got %q", dat.in, dat.expected, got)
+			continue
+		}
+		if dat.expected != "" && (len(spans) != 1 || spans[0] != dat.span) {
+			t.Errorf("ExtractPath(%s): expected span %v, but got %v", dat.in, dat.span, spans)
+		}
+	}
+}
+
+func TestExtractAll(t *testing.T) {
+	in := "On 10 March 2010 09:00 GMT, and again on 2010-03-12. Next issue: April 2010."
+	matches, err := ExtractAll(in)
+	if err != nil {
+		t.Fatalf("ExtractAll failed: %s", err)
+	}
+	expected := []string{"2010-03-10T09:00Z", "2010-03-12", "2010-04"}
+	var got []string
+	for _, m := range matches {
+		got = append(got, m.DateTime.ISOFormat())
+	}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("ExtractAll: expected %v, but got %v", expected, got)
+	}
+	if len(matches) > 0 && in[matches[0].Spans[0].Begin:matches[0].Spans[0].End] != "10 March 2010" {
+		t.Errorf("ExtractAll: bad span %v", matches[0].Spans)
+	}
+
+	// ambiguous date, with the default context
+	matches, err = ExtractAll("10 March 2010 or 10/03/2010")
+	if err == nil || len(matches) != 1 {
+		t.Errorf("ExtractAll with ambiguous date: expected 1 match and an error, got %d (%v)", len(matches), err)
+	}
+	// only the matches before the error are returned
+	matches, err = ExtractAll("1 May 2010, then 10/11/12, and 2 May 2010")
+	if err == nil || len(matches) != 1 || matches[0].DateTime.ISOFormat() != "2010-05-01" {
+		t.Errorf("ExtractAll with ambiguous date: expected 2010-05-01 and an error, got %v (%v)", matches, err)
+	}
+
+	// long input, with lots of dates, is searched a window at a time, but
+	// should give the same results as extracting from each line in turn
+	data := logLines(400)
+	matches, err = ExtractAll(data)
+	if err != nil {
+		t.Fatalf("ExtractAll on log failed: %s", err)
+	}
+	lines := strings.SplitAfter(data, "\n")
+	if len(matches) != len(lines)-1 {
+		t.Fatalf("ExtractAll on log: expected %d matches, got %d", len(lines)-1, len(matches))
+	}
+	offset := 0
+	for i, m := range matches {
+		dt, spans, _ := Extract(lines[i])
+		for j := range spans {
+			spans[j].Begin += offset
+			spans[j].End += offset
+		}
+		if m.DateTime != dt || fmt.Sprint(m.Spans) != fmt.Sprint(spans) {
+			t.Errorf("ExtractAll on log: %d: expected %s %v, got %s %v", i, dt.ISOFormat(), spans, m.DateTime.ISOFormat(), m.Spans)
+		}
+		offset += len(lines[i])
+	}
+}
+
+// logLines builds a log with a datetime on each of n lines
+func logLines(n int) string {
+	var in bytes.Buffer
+	t := time.Date(2014, 4, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		t = t.Add(time.Duration(i%13*97+1) * time.Second)
+		fmt.Fprintf(&in, "%s - request id=%d status=200\n", t.Format("2006-01-02 15:04:05"), i)
+	}
+	return in.String()
+}
+
+func TestReplace(t *testing.T) {
+	testData := []struct {
+		in     string
+		expect string
+	}{
+		{"Published on March 10th, 1999 by Brian Credability", "Published on <1999-03-10> by Brian Credability"},
+		{"From 2010-03-10 to 2010-03-11.", "From <2010-03-10> to <2010-03-11>."},
+		{"no dates here – café", "no dates here – café"},
+		{"», 10 марта 2010 «", "», <2010-03-10> «"},
+		// only the date and time are replaced, not the text around them
+		{"3 March 2009, says John Smith of the agency, at 09:15 we left", "<2009-03-03>, says John Smith of the agency, at <T09:15> we left"},
+		{"Meeting at 10:15, room 4", "Meeting at <T10:15>, room 4"},
+		{"Lunch 12:30; dinner 19:00.", "Lunch <T12:30>; dinner <T19:00>."},
+		{"Published 3 March 2009 by staff", "Published <2009-03-03> by staff"},
+		{"Posted 2010-03-10T09:00Z.", "Posted <2010-03-10T09:00Z>."},
+		{"", ""},
+	}
+	for _, dat := range testData {
+		got, err := Replace(dat.in, func(dt DateTime, span Span) string {
+			return "<" + dt.ISOFormat() + ">"
+		})
+		if err != nil {
+			t.Errorf("Replace(%q): unexpected error: %s", dat.in, err)
+			continue
+		}
+		if got != dat.expect {
+			t.Errorf("Replace(%q): expected %q, got %q", dat.in, dat.expect, got)
+		}
+	}
+
+	// the callback gets the span covering the match
+	in := "10 March 2010 at 09:00"
+	var spans []Span
+	Replace(in, func(dt DateTime, span Span) string {
+		spans = append(spans, span)
+		return ""
+	})
+	if len(spans) != 1 || in[spans[0].Begin:spans[0].End] != "10 March 2010 at 09:00" {
+		t.Errorf("unexpected spans %v", spans)
+	}
+
+	// matches before an error are still replaced
+	got, err := Replace("1 May 2010, then 10/11/12", func(dt DateTime, span Span) string { return "X" })
+	if err == nil || got != "X, then 10/11/12" {
+		t.Errorf("expected partial replacement and error, got %q, %v", got, err)
+	}
+}
+
+// spans should refer to the original text, not the normalised version
+func TestNormalisedSpans(t *testing.T) {
+	testData := []struct {
+		in       string
+		expected string // the text the spans should cover
+	}{
+		{"Date: ٢٠١٤-٠٤-١٠ (updated)", "٢٠١٤-٠٤-١٠"},
+		{"on 10 April २०१४ at １５：３０", "10 April २०१४"},
+		{"published ２０１４年４月１０日 ok", "２０１４年４月１０日"},
+		{"le 10 avril — 15∶30", "15∶30"},
+		{"\ufffd 10 April २०१४", "10 April २०१४"},
+		{"\xff\xfe 10 April २०१४", "10 April २०१४"},
+	}
+	for _, dat := range testData {
+		_, spans, err := Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", dat.in, err)
+			continue
+		}
+		if len(spans) == 0 {
+			t.Errorf("Extract(%s): no spans", dat.in)
+			continue
+		}
+		// check the first span
+		got := dat.in[spans[0].Begin:spans[0].End]
+		if got != dat.expected {
+			t.Errorf("Extract(%s): expected span to cover '%s', but got '%s'", dat.in, dat.expected, got)
+		}
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	testData := []struct {
+		in       string
+		expected string
+	}{
+		{"\ufffd 10 April २०१४", "\ufffd 10 April 2014"},
+		{"\xff 10 April २०१४", "\xff 10 April 2014"},
+		{"a\ufffd\xffb", "a\ufffd\xffb"},
+	}
+	for _, dat := range testData {
+		got, _ := normalise(dat.in)
+		if got != dat.expected {
+			t.Errorf("normalise(%q): expected %q, but got %q", dat.in, dat.expected, got)
+		}
+	}
+}
+
 // Test timezone parsing
 func TestParseTimeZone(t *testing.T) {
 	/*
@@ -299,3 +1228,432 @@ func TestTZ(t *testing.T) {
 	}
 
 }
+
+func TestCalendars(t *testing.T) {
+	ctx := DefaultContext
+	ctx.Calendars = HijriCalendar | PersianCalendar | HebrewCalendar
+
+	testData := []struct {
+		in       string
+		expected string
+		orig     CalendarDate
+	}{
+		{"10 جمادى الآخرة 1435", "2014-04-11", CalendarDate{HijriCalendar, 1435, 6, 10}},
+		{"1 رمضان 1445 هـ", "2024-03-11", CalendarDate{HijriCalendar, 1445, 9, 1}},
+		{"Ramadan 1, 1445", "2024-03-11", CalendarDate{HijriCalendar, 1445, 9, 1}},
+		{"10 Jumada al-Akhirah 1435 AH", "2014-04-11", CalendarDate{HijriCalendar, 1435, 6, 10}},
+		{"21 فروردین 1393", "2014-04-10", CalendarDate{PersianCalendar, 1393, 1, 21}},
+		{"30 Esfand 1403", "2025-03-20", CalendarDate{PersianCalendar, 1403, 12, 30}},
+		{"١٠ جمادى الآخرة ١٤٣٥", "2014-04-11", CalendarDate{HijriCalendar, 1435, 6, 10}},
+		{"۲۱ فروردین ۱۳۹۳", "2014-04-10", CalendarDate{PersianCalendar, 1393, 1, 21}},
+		{"10 Nisan 5774", "2014-04-10", CalendarDate{HebrewCalendar, 5774, 1, 10}},
+		{"י׳ בניסן תשע״ד", "2014-04-10", CalendarDate{HebrewCalendar, 5774, 1, 10}},
+		{"1 Tishrei 5775", "2014-09-25", CalendarDate{HebrewCalendar, 5775, 7, 1}},
+		{"14 Adar II 5784", "2024-03-24", CalendarDate{HebrewCalendar, 5784, 13, 14}},
+		// invalid in calendar
+		{"30 Esfand 1402", "", CalendarDate{}},
+		// plain gregorian still fine
+		{"10 April 2014", "2014-04-10", CalendarDate{}},
+	}
+	for _, dat := range testData {
+		dt, _, err := ctx.Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", dat.in, err)
+		}
+		got := dt.ISOFormat()
+		if got != dat.expected {
+			t.Errorf("Extract(%s): expected %s, but got %s", dat.in, dat.expected, got)
+		}
+		orig, _ := dt.Original()
+		if orig != dat.orig {
+			t.Errorf("Extract(%s): expected original %v, but got %v", dat.in, dat.orig, orig)
+		}
+	}
+
+	// calendars not enabled
+	dt, _, _ := Extract("21 فروردین 1393")
+	if !dt.Empty() {
+		t.Errorf("Extract(21 فروردین 1393) without calendars: got %s", dt.String())
+	}
+}
+
+func TestFuzzyNames(t *testing.T) {
+	ctx := DefaultContext
+	ctx.FuzzyNames = true
+
+	testData := []struct {
+		in       string
+		expected string
+		penalty  int
+	}{
+		{"10 April 2014", "2014-04-10", 0},
+		{"Febuary 10, 2014", "2014-02-10", 1},
+		{"10 Setpember 2014", "2014-09-10", 1},
+		{"Wednsday, 10 Setpember 2014", "2014-09-10", 2},
+		{"Septembre 2010", "2010-09", 0},
+		{"10 Sept. 2014", "2014-09-10", 0},
+		{"Dez 2013", "2013-12", 0},
+		{"3 0ct0ber 2014", "2014-10-03", 2},
+		{"10 April 2O14", "2014-04-10", 1},
+		{"1O Apri1 2O14", "2014-04-10", 3},
+		{"1st Decembre 2014", "2014-12-01", 1},
+		{"Jum 2014", "", 0}, // too short to guess
+	}
+	for _, dat := range testData {
+		dt, _, err := ctx.Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %s", dat.in, err)
+		}
+		got := dt.ISOFormat()
+		if got != dat.expected {
+			t.Errorf("Extract(%s): expected %s, but got %s", dat.in, dat.expected, got)
+		}
+		if dt.Penalty() != dat.penalty {
+			t.Errorf("Extract(%s): expected penalty %d, but got %d", dat.in, dat.penalty, dt.Penalty())
+		}
+	}
+
+	// only when enabled
+	for _, in := range []string{"Febuary 10, 2014", "Mars 2020 rover landed", "Mai 2014", "10 mai 2014", "Septembre 2010", "Dez 2013"} {
+		dt, _, _ := Extract(in)
+		if !dt.Empty() {
+			t.Errorf("Extract(%s) without fuzzy matching: got %s", in, dt.String())
+		}
+	}
+	// languages can be turned on without fuzzy matching
+	exact := DefaultContext
+	exact.Languages = "de"
+	for in, expected := range map[string]string{"10 Mai 2014": "2014-05-10", "Dez 2013": "2013-12", "10 Mia 2014": ""} {
+		dt, _, _ := exact.Extract(in)
+		if got := dt.ISOFormat(); got != expected || dt.Penalty() != 0 {
+			t.Errorf("Extract(%s) with languages %s: expected %s, got %s (penalty %d)", in, exact.Languages, expected, got, dt.Penalty())
+		}
+	}
+	// and only in the chosen languages
+	ctx.Languages = "ru"
+	dt, _, _ := ctx.Extract("Febuary 10, 2014")
+	if !dt.Empty() {
+		t.Errorf("Extract(Febuary 10, 2014) with languages %s: got %s", ctx.Languages, dt.String())
+	}
+}
+
+func TestExtractDetailed(t *testing.T) {
+	// describe the fields found, as "name=text" pairs
+	describe := func(f *Fields) string {
+		names := []string{"year", "month", "day", "weekday", "hour", "minute", "second", "fractional", "ampm", "tz"}
+		parts := []string{}
+		for i, fs := range f.all() {
+			if fs.Begin != fs.End {
+				parts = append(parts, names[i]+"="+fs.Text)
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+
+	testData := []struct {
+		ctx    *Context
+		in     string
+		expect string
+	}{
+		{&DefaultContext, "Wed Apr 16 17:32:51 NZST 2014", "year=2014 month=Apr day=16 weekday=Wed hour=17 minute=32 second=51 tz=NZST"},
+		{&DefaultContext, "Published on March 10th, 1999 by Brian Credability", "year=1999 month=March day=10"},
+		{&DefaultContext, "2010-02-01T13:14:43.123Z", "year=2010 month=02 day=01 hour=13 minute=14 second=43 fractional=123 tz=Z"},
+		{&DefaultContext, "at 4:48PM GMT", "hour=4 minute=48 ampm=PM tz=GMT"},
+		{&WesternContext, "10/11/12", "year=12 month=11 day=10"},
+		{&USContext, "10/11/2012", "year=2012 month=10 day=11"},
+		{&DefaultContext, "the 1980s", "year=1980"},
+		{&DefaultContext, "no date here", ""},
+		// only real weekdays count
+		{&DefaultContext, "Published 3 March 2009", "year=2009 month=March day=3"},
+		{&DefaultContext, "Story filed 10 April 2014", "year=2014 month=April day=10"},
+		{&DefaultContext, "Mittwoch, 10 April 2014", "year=2014 month=April day=10 weekday=Mittwoch"},
+		{&DefaultContext, "2014年5月10日（土）", "year=2014 month=5 day=10 weekday=土"},
+		// unicode digits are normalised, but the text is from the original
+		{&DefaultContext, "٢٠١٠-٠٣-١٠", "year=٢٠١٠ month=٠٣ day=١٠"},
+	}
+	for _, dat := range testData {
+		m, err := dat.ctx.ExtractDetailed(dat.in)
+		if err != nil {
+			t.Errorf("ExtractDetailed(%q): unexpected error: %s", dat.in, err)
+			continue
+		}
+		if got := describe(&m.Fields); got != dat.expect {
+			t.Errorf("ExtractDetailed(%q): expected %q, got %q", dat.in, dat.expect, got)
+		}
+		for _, fs := range m.Fields.all() {
+			if dat.in[fs.Begin:fs.End] != fs.Text {
+				t.Errorf("ExtractDetailed(%q): span %v doesn't match %q", dat.in, fs.Span, fs.Text)
+			}
+		}
+	}
+
+	// and the span starts at the date proper if there's no weekday
+	for in, expect := range map[string]string{"Published 3 March 2009": "3 March 2009", "Tue 3 March 2009": "Tue 3 March 2009"} {
+		m, _ := ExtractDetailed(in)
+		if len(m.Spans) != 1 || in[m.Spans[0].Begin:m.Spans[0].End] != expect {
+			t.Errorf("ExtractDetailed(%q): expected span over %q, got %v", in, expect, m.Spans)
+		}
+	}
+
+	// ExtractAll fills in the fields too
+	matches, err := ExtractAll("From 10 March 2010 to 11 April 2011")
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("ExtractAll: unexpected result %v, %v", matches, err)
+	}
+	if got := describe(&matches[1].Fields); got != "year=2011 month=April day=11" {
+		t.Errorf("ExtractAll: unexpected fields %q", got)
+	}
+}
+
+func TestSpanOffsets(t *testing.T) {
+	testData := []struct {
+		in    string
+		runes Span
+		utf16 Span
+	}{
+		{"Date: 10 March 2010", Span{6, 19}, Span{6, 19}},
+		// no-break space, 2 bytes in UTF-8
+		{"Опубликовано:\u00a010 March 2010", Span{14, 27}, Span{14, 27}},
+		// emoji, 4 bytes in UTF-8, a surrogate pair in UTF-16
+		{"\U0001F4C5 10 March 2010", Span{2, 15}, Span{3, 16}},
+	}
+	for _, dat := range testData {
+		_, spans, err := Extract(dat.in)
+		if err != nil || len(spans) != 1 {
+			t.Errorf("Extract(%q): unexpected result %v, %v", dat.in, spans, err)
+			continue
+		}
+		if got := spans[0].Runes(dat.in); got != dat.runes {
+			t.Errorf("%q: expected rune span %v, got %v", dat.in, dat.runes, got)
+		}
+		if got := spans[0].UTF16(dat.in); got != dat.utf16 {
+			t.Errorf("%q: expected UTF-16 span %v, got %v", dat.in, dat.utf16, got)
+		}
+		// check against the obvious conversions
+		rs := []rune(dat.in)
+		if string(rs[dat.runes.Begin:dat.runes.End]) != dat.in[spans[0].Begin:spans[0].End] {
+			t.Errorf("%q: rune span doesn't match", dat.in)
+		}
+	}
+}
+
+func TestScanner(t *testing.T) {
+	// build up some input, with a date on each line
+	var in bytes.Buffer
+	var expect []string
+	var expectSpans []Span
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&in, "%d: entry", i)
+		if i%7 == 0 {
+			in.WriteString(" Дата") // some non-ascii
+		}
+		in.WriteString(" (")
+		dt := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i*3)
+		begin := in.Len()
+		in.WriteString(dt.Format("2 January 2006"))
+		expectSpans = append(expectSpans, Span{begin, in.Len()})
+		expect = append(expect, dt.Format("2006-01-02"))
+		in.WriteString(") was logged.\n")
+	}
+	data := in.String()
+
+	for _, size := range []struct{ window, overlap int }{{1024, 128}, {100, 30}, {80, 25}} {
+		sc := NewScanner(iotest.OneByteReader(strings.NewReader(data)))
+		sc.Buffer(size.window, size.overlap)
+		n := 0
+		for sc.Scan() {
+			m := sc.Match()
+			if n >= len(expect) {
+				t.Errorf("%v: unexpected extra match %v", size, m)
+				break
+			}
+			if m.DateTime.ISOFormat() != expect[n] || len(m.Spans) != 1 || m.Spans[0] != expectSpans[n] {
+				t.Errorf("%v: %d: expected %s %v, got %s %v", size, n, expect[n], expectSpans[n], m.DateTime.ISOFormat(), m.Spans)
+			}
+			if data[m.Fields.Year.Begin:m.Fields.Year.End] != m.Fields.Year.Text {
+				t.Errorf("%v: %d: bad year field %v", size, n, m.Fields.Year)
+			}
+			n++
+		}
+		if sc.Err() != nil {
+			t.Errorf("%v: unexpected error: %s", size, sc.Err())
+		}
+		if n != len(expect) {
+			t.Errorf("%v: expected %d matches, got %d", size, len(expect), n)
+		}
+	}
+
+	// a log, dense with datetimes, should give the same results as
+	// ExtractAll, whatever the window size
+	data = logLines(400)
+	all, err := ExtractAll(data)
+	if err != nil {
+		t.Fatalf("ExtractAll on log failed: %s", err)
+	}
+	for _, size := range []struct{ window, overlap int }{{1024, 128}, {4096, 256}, {100, 30}} {
+		sc := NewScanner(strings.NewReader(data))
+		sc.Buffer(size.window, size.overlap)
+		n := 0
+		for sc.Scan() {
+			m := sc.Match()
+			if n < len(all) && (m.DateTime != all[n].DateTime || fmt.Sprint(m.Spans) != fmt.Sprint(all[n].Spans)) {
+				t.Errorf("%v: log %d: expected %s %v, got %s %v", size, n, all[n].DateTime.ISOFormat(), all[n].Spans, m.DateTime.ISOFormat(), m.Spans)
+			}
+			n++
+		}
+		if sc.Err() != nil || n != len(all) {
+			t.Errorf("%v: log: expected %d matches, got %d (%v)", size, len(all), n, sc.Err())
+		}
+	}
+
+	// data read along with an error is searched before the error is reported
+	sc := NewScanner(&errReader{data: "On 1 May 2010 ", err: iotest.ErrTimeout})
+	if !sc.Scan() || sc.match.DateTime.ISOFormat() != "2010-05-01" {
+		t.Errorf("expected match from the data read with the error")
+	}
+	if sc.Scan() || sc.Err() != iotest.ErrTimeout {
+		t.Errorf("expected %v, got %v", iotest.ErrTimeout, sc.Err())
+	}
+
+	// occasional empty reads are fine
+	sc = NewScanner(&emptyReader{r: strings.NewReader(strings.Repeat("On 1 May 2010, ", 20))})
+	n := 0
+	for sc.Scan() {
+		n++
+	}
+	if sc.Err() != nil || n != 20 {
+		t.Errorf("with empty reads: expected 20 matches, got %d (%v)", n, sc.Err())
+	}
+
+	// errors stop the scan
+	sc = NewScanner(strings.NewReader("1 May 2010, then 10/11/12"))
+	if !sc.Scan() || sc.match.DateTime.ISOFormat() != "2010-05-01" {
+		t.Errorf("expected first match before the error")
+	}
+	if sc.Scan() || sc.Err() == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// errReader returns all its data along with an error
+type errReader struct {
+	data string
+	err  error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, r.err
+}
+
+// emptyReader returns nothing on every other read, and a byte at a time
+// otherwise
+type emptyReader struct {
+	r     io.Reader
+	empty bool
+}
+
+func (r *emptyReader) Read(p []byte) (int, error) {
+	r.empty = !r.empty
+	if r.empty {
+		return 0, nil
+	}
+	return r.r.Read(p[:1])
+}
+
+func TestExtractPairing(t *testing.T) {
+	testData := []struct {
+		in     string
+		expect string
+		spans  []Span
+	}{
+		// "10.05.14 by" looks like a time with a timezone, but pairing the
+		// date with the later time gives a more complete result
+		{"10.05.14 by John Smith 10:30", "2014-05-10T10:30", []Span{{0, 8}, {23, 28}}},
+		{"Posted 10.05.14 by staff", "2014-05-10", []Span{{7, 15}}},
+		// but a date doesn't get to steal the hour from a time
+		{"April 24th 14:30 PST", "????-04-24 14:30:??-08:00", []Span{{0, 10}, {11, 20}}},
+		// spans are right when the time comes first
+		{"10:30 4 May 2014", "2014-05-04T10:30", []Span{{0, 16}}},
+		{"Thu Aug 25 10:46:55 GMT 2011", "2011-08-25T10:46:55Z", []Span{{0, 28}}},
+	}
+	for _, dat := range testData {
+		dt, spans, err := WesternContext.Extract(dat.in)
+		if err != nil {
+			t.Errorf("Extract(%q): unexpected error: %s", dat.in, err)
+			continue
+		}
+		got := dt.ISOFormat()
+		if strings.Contains(dat.expect, "?") {
+			got = dt.String()
+		}
+		if got != dat.expect {
+			t.Errorf("Extract(%q): expected %s, got %s", dat.in, dat.expect, got)
+		}
+		if fmt.Sprint(spans) != fmt.Sprint(dat.spans) {
+			t.Errorf("Extract(%q): expected spans %v, got %v", dat.in, dat.spans, spans)
+		}
+	}
+}
+
+func TestExtractPairs(t *testing.T) {
+	isos := func(matches []Match) string {
+		parts := []string{}
+		for _, m := range matches {
+			parts = append(parts, m.DateTime.ISOFormat())
+		}
+		return strings.Join(parts, ",")
+	}
+
+	testData := []struct {
+		ctx   Context
+		in    string
+		pairs string
+		dates string
+		times string
+	}{
+		{WesternContext, "Updated 14:02. Originally published 3 March 2009 at 09:15", "2009-03-03T09:15", "", "T14:02"},
+		{WesternContext, "Updated 14:02. Originally published 3 March 2009", "", "2009-03-03", "T14:02"},
+		{WesternContext, "14:00: big news. 10 March 2010", "", "2010-03-10", "T14:00"},
+		{WesternContext, "3 March 2009 at 9:15 a.m. on the dot", "2009-03-03T09:15", "", ""},
+		{WesternContext, "Thu Aug 25 10:46:55 GMT 2011", "2011-08-25T10:46:55Z", "", ""},
+		{WesternContext, "From 10/03/2010 09:00 until 12/03/2010 17:30", "2010-03-10T09:00,2010-03-12T17:30", "", ""},
+		// only full dates are paired
+		{WesternContext, "March 2010, 09:00", "", "2010-03", "T09:00"},
+		// distance
+		{WesternContext, "10 March 2010, and then a long while later at 14:00", "2010-03-10T14:00", "", ""},
+		{Context{DateResolver: DMYResolver, TZResolver: DefaultTZResolver("GB"), PairDistance: 10},
+			"10 March 2010, and then a long while later at 14:00", "", "2010-03-10", "T14:00"},
+		// counted in characters, not bytes (20 characters, 60 bytes)
+		{DefaultContext, "2014年5月10日在東京舉行的記者會上發表了新的產品計劃內容10:30", "2014-05-10T10:30", "", ""},
+	}
+	for _, dat := range testData {
+		res, err := dat.ctx.ExtractPairs(dat.in)
+		if err != nil {
+			t.Errorf("ExtractPairs(%q): unexpected error: %s", dat.in, err)
+			continue
+		}
+		if got := isos(res.Pairs); got != dat.pairs {
+			t.Errorf("ExtractPairs(%q): expected pairs %q, got %q", dat.in, dat.pairs, got)
+		}
+		if got := isos(res.Dates); got != dat.dates {
+			t.Errorf("ExtractPairs(%q): expected dates %q, got %q", dat.in, dat.dates, got)
+		}
+		if got := isos(res.Times); got != dat.times {
+			t.Errorf("ExtractPairs(%q): expected times %q, got %q", dat.in, dat.times, got)
+		}
+	}
+
+	// Extract uses the same rules
+	for in, expect := range map[string]string{
+		"Updated 14:02. Originally published 3 March 2009 at 09:15": "2009-03-03T09:15",
+		"Updated 14:02. Originally published 3 March 2009":          "2009-03-03",
+		"14:00: big news. 10 March 2010":                            "2010-03-10",
+	} {
+		dt, _, err := WesternContext.Extract(in)
+		if err != nil || dt.ISOFormat() != expect {
+			t.Errorf("Extract(%q): expected %s, got %s (%v)", in, expect, dt.ISOFormat(), err)
+		}
+	}
+}
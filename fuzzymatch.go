@@ -0,0 +1,226 @@
+package fuzzytime
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Support for approximate matching, enabled by Context.FuzzyNames.
+
+// minFuzzyLen is the shortest name we'll try to match approximately.
+// Anything shorter matches too much.
+const minFuzzyLen = 4
+
+// maxEdits returns the number of edits we'll allow when matching a
+// name of the given length (in runes)
+func maxEdits(n int) int {
+	if n <= 5 {
+		return 1
+	}
+	return 2
+}
+
+// editDistance returns the Damerau-Levenshtein distance (optimal string
+// alignment variant) between a and b, ie the number of insertions,
+// deletions, substitutions or transpositions needed to turn a into b.
+func editDistance(a, b []rune) int {
+	// d[i][j] is the distance between a[:i] and b[:j]
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			best := d[i-1][j-1] + cost // substitution
+			if d[i-1][j]+1 < best {
+				best = d[i-1][j] + 1 // deletion
+			}
+			if d[i][j-1]+1 < best {
+				best = d[i][j-1] + 1 // insertion
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < best {
+				best = d[i-2][j-2] + 1 // transposition
+			}
+			d[i][j] = best
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// fuzzyLookup finds the closest of the candidate names to s, within the
+// allowed number of edits. Names are lowercase, and a trailing '.' on
+// abbreviations is ignored. It returns the value for the name and the
+// number of edits needed, or ok=false if there is no match or the
+// closest names disagree on the value.
+func fuzzyLookup(s string, names ...map[string]int) (value int, edits int, ok bool) {
+	in := []rune(strings.TrimSuffix(s, "."))
+	if len(in) < minFuzzyLen {
+		return 0, 0, false
+	}
+	best := maxEdits(len(in)) + 1
+	for _, m := range names {
+		for name, v := range m {
+			dist := editDistance(in, []rune(name))
+			switch {
+			case dist < best:
+				best, value, ok = dist, v, true
+			case dist == best && ok && v != value:
+				ok = false // ambiguous
+			}
+		}
+	}
+	if !ok {
+		return 0, 0, false
+	}
+	return value, best, true
+}
+
+// localeMonths and localeDays map the lowercased month and weekday names
+// in locales onto their values, keyed by language code
+var localeMonths, localeDays = buildLocaleNames()
+
+func buildLocaleNames() (map[string]map[string]int, map[string]map[string]int) {
+	months := map[string]map[string]int{}
+	days := map[string]map[string]int{}
+	for lang, loc := range locales {
+		m := map[string]int{}
+		for i := range loc.months {
+			m[strings.ToLower(loc.months[i])] = i + 1
+			m[strings.ToLower(strings.TrimSuffix(loc.shortMonths[i], "."))] = i + 1
+		}
+		d := map[string]int{}
+		for i := range loc.days {
+			d[strings.ToLower(loc.days[i])] = i
+			d[strings.ToLower(strings.TrimSuffix(loc.shortDays[i], "."))] = i
+		}
+		months[lang], days[lang] = m, d
+	}
+	return months, days
+}
+
+// localeNames returns the month or weekday names for the languages listed
+// in ctx.Languages (or all of them, if none are listed)
+func (ctx *Context) localeNames(weekdays bool) []map[string]int {
+	byLang := localeMonths
+	if weekdays {
+		byLang = localeDays
+	}
+	var out []map[string]int
+	if ctx.Languages == "" {
+		for _, names := range byLang {
+			out = append(out, names)
+		}
+		return out
+	}
+	for _, lang := range strings.Split(strings.ToLower(ctx.Languages), ",") {
+		if names, ok := byLang[strings.TrimSpace(lang)]; ok {
+			out = append(out, names)
+		}
+	}
+	return out
+}
+
+// lookupLocaleName looks up a month or weekday name in the languages
+// enabled by the context (see Context.Languages). Names which need
+// correcting are only matched with FuzzyNames set, and the number of
+// edits is returned.
+func (ctx *Context) lookupLocaleName(s string, weekdays bool) (value int, edits int, ok bool) {
+	if !ctx.FuzzyNames && ctx.Languages == "" {
+		return 0, 0, false
+	}
+	names := ctx.localeNames(weekdays)
+	for _, m := range names {
+		if v, ok := m[strings.TrimSuffix(s, ".")]; ok {
+			return v, 0, true
+		}
+	}
+	if !ctx.FuzzyNames {
+		return 0, 0, false
+	}
+	return fuzzyLookup(s, names...)
+}
+
+// ocrToDigit and ocrToLetter hold characters commonly confused by OCR
+var ocrToDigit = map[byte]byte{'O': '0', 'o': '0', 'I': '1', 'l': '1', '|': '1'}
+var ocrToLetter = map[byte]byte{'0': 'o', '1': 'l'}
+
+var ordinalRE = regexp.MustCompile(`(?i)^\d+(st|nd|rd|th)$`)
+
+// ocrCorrect fixes likely OCR errors in s - letters in what look like
+// numbers (eg "2O14") and digits in what look like words (eg "0ct0ber").
+// The corrections are always ascii-for-ascii, so offsets are unchanged.
+// Returns the corrected string and the offsets of the changed bytes.
+func ocrCorrect(s string) (string, []int) {
+	var fixed []int
+	buf := []byte(s)
+	isTokenChar := func(c byte) bool {
+		return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '|'
+	}
+	for i := 0; i < len(buf); {
+		if !isTokenChar(buf[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(buf) && isTokenChar(buf[j]) {
+			j++
+		}
+		tok := buf[i:j]
+
+		var digits, letters, confusable, otherDigits int
+		for _, c := range tok {
+			switch {
+			case c >= '0' && c <= '9':
+				digits++
+				if ocrToLetter[c] == 0 {
+					otherDigits++
+				}
+			case ocrToDigit[c] != 0:
+				confusable++
+			default:
+				letters++
+			}
+		}
+		switch {
+		case ordinalRE.Match(tok):
+			// "1st", "22nd" etc are fine as they are
+		case digits > 0 && confusable > 0 && letters == 0:
+			// a number with letters in it
+			for k, c := range tok {
+				if d := ocrToDigit[c]; d != 0 {
+					tok[k] = d
+					fixed = append(fixed, i+k)
+				}
+			}
+		case digits > 0 && otherDigits == 0 && letters+confusable >= 3 && digits < letters+confusable:
+			// a word with digits in it
+			for k, c := range tok {
+				if l := ocrToLetter[c]; l != 0 {
+					tok[k] = l
+					fixed = append(fixed, i+k)
+				}
+			}
+		}
+		i = j
+	}
+	return string(buf), fixed
+}
+
+// countOffsets returns how many of the offsets fall within the span
+func countOffsets(offsets []int, span Span) int {
+	n := 0
+	for _, off := range offsets {
+		if off >= span.Begin && off < span.End {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,223 @@
+package fuzzytime
+
+import (
+	"fmt"
+	"time"
+)
+
+// humanPhrases holds the phrases used by Humanize for one language.
+// The %s verbs are filled in with a count and unit ("3 days"), a time
+// ("15:30") or a month name.
+type humanPhrases struct {
+	justNow, today, yesterday, tomorrow     string
+	thisMonth, thisYear, lastYear, nextYear string
+	ago, in                                 string       // "%s ago", "in %s"
+	yesterdayAt                             string       // "yesterday at %s"
+	tomorrowAt                              string       // "tomorrow at %s"
+	lastMonthName                           string       // "last %s"
+	nextMonthName                           string       // "next %s"
+	monthYear                               string       // "%s %d"
+	units                                   [6][2]string // singular and plural of each unit
+}
+
+// units for humanPhrases.units
+const (
+	humanMinute = iota
+	humanHour
+	humanDay
+	humanWeek
+	humanMonth
+	humanYear
+)
+
+var humanLocales = map[string]*humanPhrases{
+	"en": {
+		justNow: "just now", today: "today", yesterday: "yesterday", tomorrow: "tomorrow",
+		thisMonth: "this month", thisYear: "this year", lastYear: "last year", nextYear: "next year",
+		ago: "%s ago", in: "in %s",
+		yesterdayAt: "yesterday at %s", tomorrowAt: "tomorrow at %s",
+		lastMonthName: "last %s", nextMonthName: "next %s", monthYear: "%s %d",
+		units: [6][2]string{{"minute", "minutes"}, {"hour", "hours"}, {"day", "days"}, {"week", "weeks"}, {"month", "months"}, {"year", "years"}},
+	},
+	"es": {
+		justNow: "ahora mismo", today: "hoy", yesterday: "ayer", tomorrow: "mañana",
+		thisMonth: "este mes", thisYear: "este año", lastYear: "el año pasado", nextYear: "el próximo año",
+		ago: "hace %s", in: "dentro de %s",
+		yesterdayAt: "ayer a las %s", tomorrowAt: "mañana a las %s",
+		lastMonthName: "el pasado %s", nextMonthName: "el próximo %s", monthYear: "%s de %d",
+		units: [6][2]string{{"minuto", "minutos"}, {"hora", "horas"}, {"día", "días"}, {"semana", "semanas"}, {"mes", "meses"}, {"año", "años"}},
+	},
+	"fr": {
+		justNow: "à l'instant", today: "aujourd'hui", yesterday: "hier", tomorrow: "demain",
+		thisMonth: "ce mois-ci", thisYear: "cette année", lastYear: "l'année dernière", nextYear: "l'année prochaine",
+		ago: "il y a %s", in: "dans %s",
+		yesterdayAt: "hier à %s", tomorrowAt: "demain à %s",
+		lastMonthName: "en %s dernier", nextMonthName: "en %s prochain", monthYear: "%s %d",
+		units: [6][2]string{{"minute", "minutes"}, {"heure", "heures"}, {"jour", "jours"}, {"semaine", "semaines"}, {"mois", "mois"}, {"an", "ans"}},
+	},
+	"de": {
+		justNow: "gerade eben", today: "heute", yesterday: "gestern", tomorrow: "morgen",
+		thisMonth: "diesen Monat", thisYear: "dieses Jahr", lastYear: "letztes Jahr", nextYear: "nächstes Jahr",
+		ago: "vor %s", in: "in %s",
+		yesterdayAt: "gestern um %s", tomorrowAt: "morgen um %s",
+		lastMonthName: "letzten %s", nextMonthName: "nächsten %s", monthYear: "%s %d",
+		units: [6][2]string{{"Minute", "Minuten"}, {"Stunde", "Stunden"}, {"Tag", "Tagen"}, {"Woche", "Wochen"}, {"Monat", "Monaten"}, {"Jahr", "Jahren"}},
+	},
+}
+
+// Humanize returns a description of the datetime relative to a reference
+// time, eg "3 hours ago", "yesterday at 15:30", "in 3 weeks" or
+// "last April". The precision of the output follows the fields which
+// are set: a date with no day gives a month-level description, a date
+// with no time gives a day-level one, and so on. Dates without a year
+// and times without a date are just formatted.
+func (dt *DateTime) Humanize(ref time.Time) string {
+	return dt.HumanizeLocale(ref, "en")
+}
+
+// HumanizeLocale is like Humanize, but in the given language ("en",
+// "es", "fr" or "de"). Unknown languages fall back to English.
+func (dt *DateTime) HumanizeLocale(ref time.Time, lang string) string {
+	phrases, ok := humanLocales[lang]
+	if !ok {
+		lang = "en"
+		phrases = humanLocales[lang]
+	}
+	names, ok := locales[lang]
+	if !ok {
+		names = locales["en"]
+	}
+
+	switch {
+	case dt.Empty():
+		return ""
+	case !dt.HasYear():
+		if dt.Date.Empty() {
+			if !dt.HasHour() {
+				return ""
+			}
+			return dt.humanTime()
+		}
+		return dt.FormatLocale("2 January", lang)
+	case !dt.HasMonth():
+		switch dt.Year() - ref.Year() {
+		case 0:
+			return phrases.thisYear
+		case -1:
+			return phrases.lastYear
+		case 1:
+			return phrases.nextYear
+		}
+		return phrases.relative(dt.Year()-ref.Year(), humanYear)
+	case !dt.HasDay():
+		months := (dt.Year()*12 + dt.Month()) - (ref.Year()*12 + int(ref.Month()))
+		name := names.months[dt.Month()-1]
+		switch {
+		case months == 0:
+			return phrases.thisMonth
+		case months < 0 && months > -12:
+			return fmt.Sprintf(phrases.lastMonthName, name)
+		case months > 0 && months < 12:
+			return fmt.Sprintf(phrases.nextMonthName, name)
+		}
+		return fmt.Sprintf(phrases.monthYear, name, dt.Year())
+	}
+
+	// if there's a time, work in the reference time's location, so the
+	// day (and the time shown) is the one it was there
+	year, month, day := dt.Year(), time.Month(dt.Month()), dt.Day()
+	var t time.Time
+	if dt.HasHour() {
+		loc := ref.Location()
+		if dt.HasTZOffset() {
+			loc = time.FixedZone("", dt.TZOffset())
+		}
+		t = time.Date(year, month, day, dt.Hour(), dt.Minute(), dt.Second(), 0, loc).In(ref.Location())
+		year, month, day = t.Date()
+	}
+	days := fixedFromGregorian(year, int(month), day) -
+		fixedFromGregorian(ref.Year(), int(ref.Month()), ref.Day())
+
+	if dt.HasHour() {
+		switch days {
+		case 0:
+			if !dt.HasMinute() {
+				// only as precise as the hour
+				hours := t.Hour() - ref.Hour()
+				if hours == 0 {
+					return phrases.today
+				}
+				return phrases.relative(hours, humanHour)
+			}
+			delta := t.Sub(ref)
+			mins := int(delta / time.Minute)
+			switch {
+			case mins == 0:
+				return phrases.justNow
+			case mins > -60 && mins < 60:
+				return phrases.relative(mins, humanMinute)
+			}
+			return phrases.relative(int(delta/time.Hour), humanHour)
+		case -1:
+			return fmt.Sprintf(phrases.yesterdayAt, clockTime(t, dt.HasMinute()))
+		case 1:
+			return fmt.Sprintf(phrases.tomorrowAt, clockTime(t, dt.HasMinute()))
+		}
+	}
+
+	abs := days
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case days == 0:
+		return phrases.today
+	case days == -1:
+		return phrases.yesterday
+	case days == 1:
+		return phrases.tomorrow
+	case abs < 7:
+		return phrases.relative(days, humanDay)
+	case abs < 30:
+		return phrases.relative(days/7, humanWeek)
+	case abs < 365:
+		months := (dt.Year()*12 + dt.Month()) - (ref.Year()*12 + int(ref.Month()))
+		if months == 0 {
+			// eg 30 days, but spanning a short month
+			months = days / abs
+		}
+		return phrases.relative(months, humanMonth)
+	}
+	return phrases.relative(days/365, humanYear)
+}
+
+// relative returns "n units ago" or "in n units"
+func (p *humanPhrases) relative(n int, unit int) string {
+	phrase := p.in
+	if n < 0 {
+		phrase = p.ago
+		n = -n
+	}
+	name := p.units[unit][1]
+	if n == 1 {
+		name = p.units[unit][0]
+	}
+	return fmt.Sprintf(phrase, fmt.Sprintf("%d %s", n, name))
+}
+
+// humanTime returns the time as "15:04", treating a missing minute as
+// on the hour
+func (dt *DateTime) humanTime() string {
+	if dt.HasMinute() {
+		return fmt.Sprintf("%02d:%02d", dt.Hour(), dt.Minute())
+	}
+	return fmt.Sprintf("%02d:00", dt.Hour())
+}
+
+// clockTime is humanTime for a time.Time
+func clockTime(t time.Time, hasMinute bool) string {
+	if hasMinute {
+		return t.Format("15:04")
+	}
+	return t.Format("15:00")
+}
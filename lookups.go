@@ -8,25 +8,60 @@ package fuzzytime
 // useful reference for month abbreviations:
 // http://library.princeton.edu/departments/tsd/katmandu/reference/months.html
 
-/*
-dayLookup = {
-    'mon': 'mon', 'monday': 'mon',
-    'tue': 'tue', 'tuesday': 'tue',
-    'wed': 'wed', 'wednesday': 'wed',
-    'thu': 'thu', 'thursday': 'thu',
-    'fri': 'fri', 'friday': 'fri',
-    'sat': 'sat', 'saturday': 'sat',
-    'sun': 'sun', 'sunday': 'sun',
-    # es
-    'lunes': 'mon',
-    'martes': 'tue',
-    'miércoles': 'wed',
-    'jueves': 'thu',
-    'viernes': 'fri',
-    'sábado': 'sat',
-    'domingo': 'sun',
+// dayLookup maps weekday names to time.Weekday values (sunday=0)
+var dayLookup = map[string]int{
+	"sun": 0, "sunday": 0,
+	"mon": 1, "monday": 1,
+	"tue": 2, "tues": 2, "tuesday": 2,
+	"wed": 3, "weds": 3, "wednesday": 3,
+	"thu": 4, "thur": 4, "thurs": 4, "thursday": 4,
+	"fri": 5, "friday": 5,
+	"sat": 6, "saturday": 6,
+
+	// es
+	"domingo":   0,
+	"lunes":     1,
+	"martes":    2,
+	"miércoles": 3,
+	"jueves":    4,
+	"viernes":   5,
+	"sábado":    6,
+
+	// de
+	"sonntag":    0,
+	"montag":     1,
+	"dienstag":   2,
+	"mittwoch":   3,
+	"donnerstag": 4,
+	"freitag":    5,
+	"samstag":    6,
+	"sonnabend":  6,
+
+	// fr
+	"dimanche": 0,
+	"lundi":    1,
+	"mardi":    2,
+	"mercredi": 3,
+	"jeudi":    4,
+	"vendredi": 5,
+	"samedi":   6,
+
+	// ru
+	"воскресенье": 0,
+	"понедельник": 1,
+	"вторник":     2,
+	"среда":       3,
+	"четверг":     4,
+	"пятница":     5,
+	"суббота":     6,
+
+	// ja, ko, zh (in brackets after the date, eg "（土）")
+	"日": 0, "月": 1, "火": 2, "水": 3, "木": 4, "金": 5, "土": 6,
+	"일": 0, "월": 1, "화": 2, "수": 3, "목": 4, "금": 5, "토": 6,
+	"一": 1, "二": 2, "三": 3, "四": 4, "五": 5, "六": 6, "天": 0,
+	"星期日": 0, "星期天": 0, "星期一": 1, "星期二": 2, "星期三": 3, "星期四": 4, "星期五": 5, "星期六": 6,
+	"周日": 0, "周一": 1, "周二": 2, "周三": 3, "周四": 4, "周五": 5, "周六": 6,
 }
-*/
 
 var monthLookup = map[string]int{
 	"jan": 1,
@@ -134,4 +169,311 @@ var monthLookup = map[string]int{
 	"ноябрь":   11,
 	"декабря":  12,
 	"декабрь":  12,
+
+	// th - full
+	"มกราคม":     1,
+	"กุมภาพันธ์": 2,
+	"มีนาคม":     3,
+	"เมษายน":     4,
+	"พฤษภาคม":    5,
+	"มิถุนายน":   6,
+	"กรกฎาคม":    7,
+	"สิงหาคม":    8,
+	"กันยายน":    9,
+	"ตุลาคม":     10,
+	"พฤศจิกายน":  11,
+	"ธันวาคม":    12,
+
+	// th - abbreviations
+	"ม.ค.":  1,
+	"ก.พ.":  2,
+	"มี.ค.": 3,
+	"เม.ย.": 4,
+	"พ.ค.":  5,
+	"มิ.ย.": 6,
+	"ก.ค.":  7,
+	"ส.ค.":  8,
+	"ก.ย.":  9,
+	"ต.ค.":  10,
+	"พ.ย.":  11,
+	"ธ.ค.":  12,
+}
+
+// localeNames holds the month and weekday names for a language, as used
+// for formatting, and for parsing when enabled by Context.Languages or
+// Context.FuzzyNames.
+type localeNames struct {
+	months      [12]string // full month names, January first
+	shortMonths [12]string
+	days        [7]string // full weekday names, Sunday first
+	shortDays   [7]string
+	// genitiveMonths are the month names used after a day, for languages
+	// which inflect them (eg "10 апреля")
+	genitiveMonths [12]string
+}
+
+// locales holds names for each language we know about, keyed by
+// language code
+var locales = map[string]*localeNames{
+	"en": {
+		months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		shortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"es": {
+		months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"de": {
+		months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"fr": {
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"ru": {
+		months:         [12]string{"январь", "февраль", "март", "апрель", "май", "июнь", "июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь"},
+		shortMonths:    [12]string{"янв", "фев", "мар", "апр", "май", "июн", "июл", "авг", "сен", "окт", "ноя", "дек"},
+		days:           [7]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"},
+		shortDays:      [7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"},
+		genitiveMonths: [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+	},
+}
+
+// offsets to convert era years to Gregorian years
+const (
+	minguoOffset   = 1911
+	buddhistOffset = -543
+)
+
+// eraLookup maps era markers to the era system and the offset to convert
+// a year in that era to a Gregorian one (ie gregorian = offset + year).
+var eraLookup = map[string]struct {
+	era    Era
+	offset int
+}{
+	// ja
+	"明治": {JapaneseEra, 1867},
+	"大正": {JapaneseEra, 1911},
+	"昭和": {JapaneseEra, 1925},
+	"平成": {JapaneseEra, 1988},
+	"令和": {JapaneseEra, 2018},
+
+	// zh (taiwan)
+	"民國":   {MinguoEra, minguoOffset},
+	"民国":   {MinguoEra, minguoOffset},
+	"中華民國": {MinguoEra, minguoOffset},
+	"中华民国": {MinguoEra, minguoOffset},
+
+	// th
+	"พ.ศ.": {BuddhistEra, buddhistOffset},
+	"พ.ศ":  {BuddhistEra, buddhistOffset},
+	"ค.ศ.": {0, 0}, // Christian era (ie Gregorian)
+	"ค.ศ":  {0, 0},
+}
+
+// calendarMonthLookup holds month names for the non-Gregorian calendars,
+// in their native scripts and in common transliterations. When matching,
+// case, spacing, hyphens, apostrophes, vowel marks and some
+// letter variants are ignored (see calendarKey), so they don't all need
+// to be listed here.
+var calendarMonthLookup = map[Calendar]map[string]int{
+	HijriCalendar: {
+		"محرم":          1,
+		"المحرم":        1,
+		"صفر":           2,
+		"ربيع الأول":    3,
+		"ربيع الثاني":   4,
+		"ربيع الآخر":    4,
+		"جمادى الأولى":  5,
+		"جمادى الأول":   5,
+		"جمادى الآخرة":  6,
+		"جمادى الثانية": 6,
+		"جمادى الآخر":   6,
+		"رجب":           7,
+		"شعبان":         8,
+		"رمضان":         9,
+		"شوال":          10,
+		"ذو القعدة":     11,
+		"ذي القعدة":     11,
+		"ذو الحجة":      12,
+		"ذي الحجة":      12,
+
+		"muharram":          1,
+		"moharram":          1,
+		"safar":             2,
+		"rabi al-awwal":     3,
+		"rabi ul-awwal":     3,
+		"rabi i":            3,
+		"rabi al-thani":     4,
+		"rabi al-akhir":     4,
+		"rabi ul-akhir":     4,
+		"rabi ul-thani":     4,
+		"rabi ii":           4,
+		"jumada al-awwal":   5,
+		"jumada al-ula":     5,
+		"jumada al-oula":    5,
+		"jumada i":          5,
+		"jumada al-thani":   6,
+		"jumada al-akhirah": 6,
+		"jumada al-akhira":  6,
+		"jumada al-akhir":   6,
+		"jumada ii":         6,
+		"rajab":             7,
+		"shaban":            8,
+		"ramadan":           9,
+		"ramadhan":          9,
+		"ramazan":           9,
+		"shawwal":           10,
+		"shawal":            10,
+		"dhu al-qadah":      11,
+		"dhul-qadah":        11,
+		"dhu al-qidah":      11,
+		"dhul-qidah":        11,
+		"dhu al-hijjah":     12,
+		"dhul-hijjah":       12,
+		"dhu al-hijja":      12,
+		"dhul-hijja":        12,
+	},
+	PersianCalendar: {
+		"فروردین":  1,
+		"اردیبهشت": 2,
+		"خرداد":    3,
+		"تیر":      4,
+		"مرداد":    5,
+		"امرداد":   5,
+		"شهریور":   6,
+		"مهر":      7,
+		"آبان":     8,
+		"آذر":      9,
+		"دی":       10,
+		"بهمن":     11,
+		"اسفند":    12,
+
+		"farvardin":   1,
+		"ordibehesht": 2,
+		"khordad":     3,
+		"tir":         4,
+		"mordad":      5,
+		"amordad":     5,
+		"shahrivar":   6,
+		"mehr":        7,
+		"aban":        8,
+		"azar":        9,
+		"dey":         10,
+		"dei":         10,
+		"bahman":      11,
+		"esfand":      12,
+		"espand":      12,
+	},
+	HebrewCalendar: {
+		"ניסן":      1,
+		"אייר":      2,
+		"איר":       2,
+		"סיון":      3,
+		"סיוון":     3,
+		"תמוז":      4,
+		"אב":        5,
+		"מנחם אב":   5,
+		"אלול":      6,
+		"תשרי":      7,
+		"חשון":      8,
+		"חשוון":     8,
+		"מרחשון":    8,
+		"מרחשוון":   8,
+		"כסלו":      9,
+		"כסליו":     9,
+		"טבת":       10,
+		"שבט":       11,
+		"אדר":       12,
+		"אדר א'":    12,
+		"אדר ראשון": 12,
+		"אדר ב'":    13,
+		"אדר שני":   13,
+
+		"nisan":       1,
+		"nissan":      1,
+		"iyar":        2,
+		"iyyar":       2,
+		"sivan":       3,
+		"tammuz":      4,
+		"tamuz":       4,
+		"av":          5,
+		"menachem av": 5,
+		"elul":        6,
+		"tishrei":     7,
+		"tishri":      7,
+		"cheshvan":    8,
+		"heshvan":     8,
+		"marcheshvan": 8,
+		"marheshvan":  8,
+		"kislev":      9,
+		"tevet":       10,
+		"teves":       10,
+		"shevat":      11,
+		"shvat":       11,
+		"adar":        12,
+		"adar i":      12,
+		"adar 1":      12,
+		"adar aleph":  12,
+		"adar ii":     13,
+		"adar 2":      13,
+		"adar bet":    13,
+		"adar beit":   13,
+		"adar sheni":  13,
+	},
+}
+
+// hebrewNumerals holds the values of the Hebrew letters, for dates written
+// in gematria (eg "י״ד")
+var hebrewNumerals = map[rune]int{
+	'א': 1, 'ב': 2, 'ג': 3, 'ד': 4, 'ה': 5, 'ו': 6, 'ז': 7, 'ח': 8, 'ט': 9,
+	'י': 10, 'כ': 20, 'ך': 20, 'ל': 30, 'מ': 40, 'ם': 40, 'נ': 50, 'ן': 50,
+	'ס': 60, 'ע': 70, 'פ': 80, 'ף': 80, 'צ': 90, 'ץ': 90,
+	'ק': 100, 'ר': 200, 'ש': 300, 'ת': 400,
+}
+
+// periodLookup maps names of parts of the year onto Periods. Multi-word
+// names are stored with single spaces.
+var periodLookup = map[string]Period{
+	"spring": Spring,
+	"summer": Summer,
+	"autumn": Autumn,
+	"fall":   Autumn,
+	"winter": Winter,
+
+	"q1":             Q1,
+	"q2":             Q2,
+	"q3":             Q3,
+	"q4":             Q4,
+	"first quarter":  Q1,
+	"second quarter": Q2,
+	"third quarter":  Q3,
+	"fourth quarter": Q4,
+	"1st quarter":    Q1,
+	"2nd quarter":    Q2,
+	"3rd quarter":    Q3,
+	"4th quarter":    Q4,
+
+	"h1":          H1,
+	"h2":          H2,
+	"first half":  H1,
+	"second half": H2,
+	"1st half":    H1,
+	"2nd half":    H2,
+}
+
+var qualifierLookup = map[string]Qualifier{
+	"early":  Early,
+	"mid":    Mid,
+	"middle": Mid,
+	"late":   Late,
 }
@@ -0,0 +1,563 @@
+package fuzzytime
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Serialisation.
+// Date, Time and DateTime are written out using ISOFormat() when that
+// doesn't lose anything (ie the set fields are contiguous, eg "2010-04"
+// or "T14:51"), and String() otherwise (eg "????-04-24 ??:??:??").
+// Dates with a period or uncertainty are written with EDTFFormat()
+// instead (eg "2011-35" for Q3 2011, "2004?-06"), and anything EDTF can't
+// express is added in square brackets, in the style of RFC 9557:
+// "2010[_qualifier=early]", "2014-05-10[_era=japanese]" or
+// "2014-05-09[_orig=hijri:1435-07-10]".
+// All the forms read back exactly, so the round trip preserves which
+// fields were set, along with the timezone, fractional seconds and the
+// extra information. Only the timezone name (see Time.TZName) and the
+// penalty from fuzzy matching are dropped, so plain ISO 8601 datetimes
+// stay plain.
+
+// isoLossless returns true if ISOFormat() captures all the set fields
+func (d *Date) isoLossless() bool {
+	if !d.HasYear() {
+		return d.Empty()
+	}
+	return d.HasMonth() || !d.HasDay()
+}
+
+// isoLossless returns true if ISOFormat() captures all the set fields
+func (t *Time) isoLossless() bool {
+	if !t.HasHour() {
+		return t.Empty()
+	}
+	if !t.HasMinute() {
+		return !t.HasSecond() && !t.HasFractional()
+	}
+	return t.HasSecond() || !t.HasFractional()
+}
+
+// plain returns true if the date has nothing beyond its fields to
+// serialise
+func (d *Date) plain() bool {
+	return d.period == NoPeriod && d.qualifier == NoQualifier && d.era == 0 &&
+		d.orig.Calendar == 0 && d.uncertain == 0 && d.approx == 0
+}
+
+// annotations returns the extra information about the date which EDTF
+// can't express, as RFC 9557-style suffixes
+func (d *Date) annotations() string {
+	out := ""
+	if d.qualifier != NoQualifier {
+		out += "[_qualifier=" + d.qualifier.String() + "]"
+	}
+	if d.era != 0 {
+		out += "[_era=" + strings.ToLower(d.era.String()) + "]"
+	}
+	if d.orig.Calendar != 0 {
+		orig := NewDate(d.orig.Year, d.orig.Month, d.orig.Day)
+		out += "[_orig=" + strings.ToLower(d.orig.Calendar.String()) + ":" + orig.ISOFormat() + "]"
+	}
+	return out
+}
+
+// baseText returns the serialised form of the date, without annotations
+func (d *Date) baseText() string {
+	if !d.plain() {
+		return d.EDTFFormat()
+	}
+	if d.isoLossless() {
+		return d.ISOFormat()
+	}
+	return d.String()
+}
+
+// text returns the serialised form of the time
+func (t *Time) text() string {
+	if t.isoLossless() {
+		return t.ISOFormat()
+	}
+	return t.String()
+}
+
+// text returns the serialised form of the date
+func (d *Date) text() string { return d.baseText() + d.annotations() }
+
+// text returns the serialised form of the datetime
+func (dt *DateTime) text() string {
+	var out string
+	switch {
+	case !dt.Date.plain():
+		out = dt.Date.EDTFFormat()
+		if !dt.Time.Empty() {
+			out += "T" + dt.Time.text()
+		}
+	case dt.Date.isoLossless() && dt.Time.isoLossless():
+		out = dt.ISOFormat()
+	default:
+		out = dt.String()
+	}
+	return out + dt.Date.annotations()
+}
+
+var (
+	isoDateRE    = regexp.MustCompile(`^(-?\d{4,})(?:-(\d{2})(?:-(\d{2}))?)?$`)
+	stringDateRE = regexp.MustCompile(`^(\?{4}|-?\d{4,})-(\?\?|\d{2})-(\?\?|\d{2})$`)
+	isoTimeRE    = regexp.MustCompile(`^(\d{2})(?::(\d{2})(?::(\d{2})(?:\.(\d{3}))?)?)?(Z|[-+]\d{2}:\d{2})?$`)
+	stringTimeRE = regexp.MustCompile(`^(\?\?|\d{2}):(\?\?|\d{2}):(\?\?|\d{2})(?:\.(\d{3}))?(Z|[-+]\d{2}:\d{2})?$`)
+	annotationRE = regexp.MustCompile(`\[(_[a-z]+)=([^\]]*)\]$`)
+	origRE       = regexp.MustCompile(`^([a-z]+):(\d+)-(\d{2})-(\d{2})$`)
+)
+
+// splitAnnotations removes the annotations added by Date.annotations()
+// from the end of s, and returns them keyed by name
+func splitAnnotations(s string) (string, map[string]string) {
+	var out map[string]string
+	for {
+		m := annotationRE.FindStringSubmatchIndex(s)
+		if m == nil {
+			return s, out
+		}
+		if out == nil {
+			out = map[string]string{}
+		}
+		out[s[m[2]:m[3]]] = s[m[4]:m[5]]
+		s = s[:m[0]]
+	}
+}
+
+// applyAnnotations sets the extra information in the annotations on d
+func applyAnnotations(d *Date, annotations map[string]string) error {
+	for key, val := range annotations {
+		switch key {
+		case "_qualifier":
+			q := Qualifier(0)
+			for _, candidate := range []Qualifier{Early, Mid, Late} {
+				if val == candidate.String() {
+					q = candidate
+				}
+			}
+			if q == NoQualifier {
+				return errors.New("bad qualifier")
+			}
+			d.SetQualifier(q)
+		case "_era":
+			era := Era(0)
+			for _, candidate := range []Era{JapaneseEra, MinguoEra, BuddhistEra} {
+				if val == strings.ToLower(candidate.String()) {
+					era = candidate
+				}
+			}
+			if era == 0 {
+				return errors.New("bad era")
+			}
+			d.SetEra(era)
+		case "_orig":
+			m := origRE.FindStringSubmatch(val)
+			if m == nil {
+				return errors.New("bad original date")
+			}
+			var orig CalendarDate
+			for _, candidate := range []Calendar{HijriCalendar, PersianCalendar, HebrewCalendar} {
+				if m[1] == strings.ToLower(candidate.String()) {
+					orig.Calendar = candidate
+				}
+			}
+			if orig.Calendar == 0 {
+				return errors.New("bad calendar")
+			}
+			orig.Year, _ = strconv.Atoi(m[2])
+			orig.Month, _ = strconv.Atoi(m[3])
+			orig.Day, _ = strconv.Atoi(m[4])
+			d.orig = orig
+		default:
+			return errors.New("unknown annotation " + key)
+		}
+	}
+	return nil
+}
+
+// parseEDTFText parses the serialised form of a date which isn't plain:
+// an EDTF date, maybe followed by a time
+func parseEDTFText(s string) (DateTime, error) {
+	datePart, timePart := s, ""
+	if idx := strings.IndexByte(s, 'T'); idx >= 0 {
+		datePart, timePart = s[:idx], s[idx+1:]
+		if timePart == "" {
+			return DateTime{}, errors.New("missing time")
+		}
+	}
+	dt, err := parseEDTFDateTime(datePart)
+	if err != nil {
+		return DateTime{}, err
+	}
+	if dt.Time, err = parseTimeText(timePart); err != nil {
+		return DateTime{}, err
+	}
+	return dt, nil
+}
+
+// parseDateFields fills out a Date from year, month and day strings.
+// Blank or question-marked fields are left unset.
+func parseDateFields(year, month, day string) (Date, error) {
+	var d Date
+	unset := func(s string) bool { return s == "" || s[0] == '?' }
+	if !unset(year) {
+		y, err := strconv.Atoi(year)
+		if err != nil || y == 0 {
+			return Date{}, errors.New("bad year")
+		}
+		d.SetYear(y)
+	}
+	if !unset(month) {
+		m, err := strconv.Atoi(month)
+		if err != nil || m < 1 || m > 12 {
+			return Date{}, errors.New("bad month")
+		}
+		d.SetMonth(m)
+	}
+	if !unset(day) {
+		dd, err := strconv.Atoi(day)
+		if err != nil || dd < 1 || dd > 31 {
+			return Date{}, errors.New("bad day")
+		}
+		d.SetDay(dd)
+	}
+	return d, nil
+}
+
+// parseTimeFields fills out a Time from its component strings.
+// Blank or question-marked fields are left unset.
+func parseTimeFields(hour, minute, second, fractional, tz string) (Time, error) {
+	var t Time
+	unset := func(s string) bool { return s == "" || s[0] == '?' }
+	if !unset(hour) {
+		h, err := strconv.Atoi(hour)
+		if err != nil || h > 23 {
+			return Time{}, errors.New("bad hour")
+		}
+		t.SetHour(h)
+	}
+	if !unset(minute) {
+		m, err := strconv.Atoi(minute)
+		if err != nil || m > 59 {
+			return Time{}, errors.New("bad minute")
+		}
+		t.SetMinute(m)
+	}
+	if !unset(second) {
+		s, err := strconv.Atoi(second)
+		if err != nil || s > 59 {
+			return Time{}, errors.New("bad second")
+		}
+		t.SetSecond(s)
+	}
+	if fractional != "" {
+		f, err := strconv.Atoi(fractional)
+		if err != nil {
+			return Time{}, errors.New("bad fractional second")
+		}
+		t.SetFractional(f)
+	}
+	if tz != "" {
+		offset, err := TZToOffset(tz)
+		if err != nil {
+			return Time{}, err
+		}
+		t.SetTZOffset(offset)
+	}
+	return t, nil
+}
+
+// parseDateText parses the output of Date.ISOFormat() or Date.String(),
+// or the EDTF form used for dates which aren't plain, along with any
+// annotations
+func parseDateText(s string) (Date, error) {
+	base, annotations := splitAnnotations(s)
+	var dt DateTime
+	var err error
+	if m := isoDateRE.FindStringSubmatch(base); m != nil {
+		dt.Date, err = parseDateFields(m[1], m[2], m[3])
+	} else if m := stringDateRE.FindStringSubmatch(base); m != nil {
+		dt.Date, err = parseDateFields(m[1], m[2], m[3])
+	} else if base != "" {
+		err = errors.New("bad date")
+	}
+	if err != nil && !strings.ContainsRune(base, 'T') {
+		dt, err = parseEDTFText(base)
+	}
+	if err != nil {
+		return Date{}, errors.New("bad date")
+	}
+	if err := applyAnnotations(&dt.Date, annotations); err != nil {
+		return Date{}, err
+	}
+	return dt.Date, nil
+}
+
+// parseTimeText parses the output of Time.ISOFormat() or Time.String()
+func parseTimeText(s string) (Time, error) {
+	if s == "" {
+		return Time{}, nil
+	}
+	if m := isoTimeRE.FindStringSubmatch(s); m != nil {
+		return parseTimeFields(m[1], m[2], m[3], m[4], m[5])
+	}
+	if m := stringTimeRE.FindStringSubmatch(s); m != nil {
+		return parseTimeFields(m[1], m[2], m[3], m[4], m[5])
+	}
+	return Time{}, errors.New("bad time")
+}
+
+// parseDateTimeText parses the output of DateTime.ISOFormat() or
+// DateTime.String(), or the EDTF form used for dates which aren't plain,
+// along with any annotations
+func parseDateTimeText(s string) (DateTime, error) {
+	base, annotations := splitAnnotations(s)
+	var dt DateTime
+	var err error
+	if strings.Contains(base, " ") {
+		dt, err = ParseString(base)
+	} else if dt, err = ParseISO(base); err != nil {
+		if edtf, e := parseEDTFText(base); e == nil {
+			dt, err = edtf, nil
+		}
+	}
+	if err != nil {
+		return DateTime{}, err
+	}
+	if err := applyAnnotations(&dt.Date, annotations); err != nil {
+		return DateTime{}, err
+	}
+	return dt, nil
+}
+
+// ParseString parses the output of DateTime.String() back into a
+// DateTime, eg "2010-04-24 14:51:??" or "????-04-24 ??:??:??+01:00".
+// Question-marked fields are left unset.
+func ParseString(s string) (DateTime, error) {
+	idx := strings.IndexByte(s, ' ')
+	if idx < 0 {
+		return DateTime{}, errors.New("missing time")
+	}
+	dm := stringDateRE.FindStringSubmatch(s[:idx])
+	if dm == nil {
+		return DateTime{}, errors.New("bad date")
+	}
+	tm := stringTimeRE.FindStringSubmatch(s[idx+1:])
+	if tm == nil {
+		return DateTime{}, errors.New("bad time")
+	}
+	return dateTimeFromFields(dm, tm)
+}
+
+// ParseISO parses the output of DateTime.ISOFormat() back into a
+// DateTime. The date and time can be truncated ("2010-04", "2010-04-24T14")
+// and the date can be missing altogether ("T14:51"). Timezones are
+// "Z" or "+HH:MM". An empty string gives an empty DateTime.
+func ParseISO(s string) (DateTime, error) {
+	datePart, timePart := s, ""
+	idx := strings.IndexByte(s, 'T')
+	if idx >= 0 {
+		datePart, timePart = s[:idx], s[idx+1:]
+		if timePart == "" {
+			return DateTime{}, errors.New("missing time")
+		}
+	}
+	dm := []string{"", "", "", ""}
+	if datePart != "" {
+		if dm = isoDateRE.FindStringSubmatch(datePart); dm == nil {
+			return DateTime{}, errors.New("bad date")
+		}
+	}
+	tm := []string{"", "", "", "", "", ""}
+	if timePart != "" {
+		if tm = isoTimeRE.FindStringSubmatch(timePart); tm == nil {
+			return DateTime{}, errors.New("bad time")
+		}
+	}
+	return dateTimeFromFields(dm, tm)
+}
+
+// dateTimeFromFields builds a DateTime from date and time submatches
+func dateTimeFromFields(dm, tm []string) (DateTime, error) {
+	var dt DateTime
+	var err error
+	if dt.Date, err = parseDateFields(dm[1], dm[2], dm[3]); err != nil {
+		return DateTime{}, err
+	}
+	if dt.Time, err = parseTimeFields(tm[1], tm[2], tm[3], tm[4], tm[5]); err != nil {
+		return DateTime{}, err
+	}
+	return dt, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Date) MarshalText() ([]byte, error) { return []byte(d.text()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Date) UnmarshalText(data []byte) error {
+	parsed, err := parseDateText(string(data))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Date) MarshalJSON() ([]byte, error) { return json.Marshal(d.text()) }
+
+// UnmarshalJSON implements json.Unmarshaler. A JSON null leaves the
+// date unchanged.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, d.UnmarshalText)
+}
+
+// Value implements driver.Valuer. An empty date is stored as NULL.
+func (d Date) Value() (driver.Value, error) {
+	if d.Empty() {
+		return nil, nil
+	}
+	return d.text(), nil
+}
+
+// Scan implements sql.Scanner. It accepts text, NULL (an empty date)
+// or a time.Time.
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = *NewDate(v.Year(), int(v.Month()), v.Day())
+		return nil
+	}
+	return scanText(src, d.UnmarshalText)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t Time) MarshalText() ([]byte, error) { return []byte(t.text()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Time) UnmarshalText(data []byte) error {
+	parsed, err := parseTimeText(string(data))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Time) MarshalJSON() ([]byte, error) { return json.Marshal(t.text()) }
+
+// UnmarshalJSON implements json.Unmarshaler. A JSON null leaves the
+// time unchanged.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, t.UnmarshalText)
+}
+
+// Value implements driver.Valuer. An empty time is stored as NULL.
+func (t Time) Value() (driver.Value, error) {
+	if t.Empty() {
+		return nil, nil
+	}
+	return t.text(), nil
+}
+
+// Scan implements sql.Scanner. It accepts text, NULL (an empty time)
+// or a time.Time.
+func (t *Time) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*t = timeFromStdlib(v)
+		return nil
+	}
+	return scanText(src, t.UnmarshalText)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (dt DateTime) MarshalText() ([]byte, error) { return []byte(dt.text()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (dt *DateTime) UnmarshalText(data []byte) error {
+	parsed, err := parseDateTimeText(string(data))
+	if err != nil {
+		return err
+	}
+	*dt = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (dt DateTime) MarshalJSON() ([]byte, error) { return json.Marshal(dt.text()) }
+
+// UnmarshalJSON implements json.Unmarshaler. A JSON null leaves the
+// datetime unchanged.
+func (dt *DateTime) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, dt.UnmarshalText)
+}
+
+// Value implements driver.Valuer. An empty datetime is stored as NULL.
+func (dt DateTime) Value() (driver.Value, error) {
+	if dt.Empty() {
+		return nil, nil
+	}
+	return dt.text(), nil
+}
+
+// Scan implements sql.Scanner. It accepts text, NULL (an empty datetime)
+// or a time.Time.
+func (dt *DateTime) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		dt.Date = *NewDate(v.Year(), int(v.Month()), v.Day())
+		dt.Time = timeFromStdlib(v)
+		return nil
+	}
+	return scanText(src, dt.UnmarshalText)
+}
+
+// timeFromStdlib returns a Time with all fields set from t.
+// Fractional seconds are truncated to milliseconds.
+func timeFromStdlib(t time.Time) Time {
+	var out Time
+	_, offset := t.Zone()
+	out.SetHour(t.Hour())
+	out.SetMinute(t.Minute())
+	out.SetSecond(t.Second())
+	out.SetFractional(t.Nanosecond() / int(time.Millisecond))
+	out.SetTZOffset(offset)
+	return out
+}
+
+// unmarshalJSONText decodes a JSON string and passes it on to an
+// UnmarshalText function
+func unmarshalJSONText(data []byte, unmarshal func([]byte) error) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return unmarshal([]byte(s))
+}
+
+// scanText handles the textual cases for the sql.Scanner implementations
+func scanText(src interface{}, unmarshal func([]byte) error) error {
+	switch v := src.(type) {
+	case nil:
+		return unmarshal(nil)
+	case string:
+		return unmarshal([]byte(v))
+	case []byte:
+		return unmarshal(v)
+	}
+	return errors.New("unsupported type for scan")
+}
@@ -0,0 +1,83 @@
+package fuzzytime
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// separatorVariants maps unicode dashes, slashes and colons to the plain
+// ascii separators the crackers expect.
+var separatorVariants = map[rune]rune{
+	// dashes
+	'‐': '-', '‑': '-', '‒': '-', '–': '-', '—': '-', '―': '-',
+	'−': '-', '﹘': '-', '﹣': '-', '－': '-',
+	// slashes
+	'⁄': '/', '∕': '/', '⧸': '/', '／': '/',
+	// colons
+	'：': ':', '∶': ':', '﹕': ':', '꞉': ':',
+}
+
+// offsetMap maps byte offsets in a normalised string back to the
+// original string. A nil offsetMap is the identity.
+type offsetMap []int
+
+// span converts a span in the normalised string to the original one
+func (om offsetMap) span(sp Span) Span {
+	if om == nil || sp.Begin == sp.End {
+		return sp
+	}
+	return Span{om[sp.Begin], om[sp.End]}
+}
+
+// normalise maps any unicode decimal digits to ascii ('٣' to '3' etc)
+// and unicode separator variants to their ascii equivalents, so the
+// crackers only have to deal with plain ascii.
+// It returns the normalised string, along with a map to convert offsets
+// back into the original string.
+func normalise(s string) (string, offsetMap) {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s, nil
+	}
+
+	var out bytes.Buffer
+	om := make(offsetMap, 0, len(s)+1)
+	for i, rlen := 0, 0; i < len(s); i += rlen {
+		// invalid utf-8 decodes with a width of 1, so is copied through
+		// byte by byte (a genuine U+FFFD is copied whole)
+		var r rune
+		r, rlen = utf8.DecodeRuneInString(s[i:])
+		repl, ok := separatorVariants[r]
+		if !ok && r >= utf8.RuneSelf && unicode.IsDigit(r) {
+			repl, ok = '0'+digitValue(r), true
+		}
+		if ok {
+			om = append(om, i)
+			out.WriteRune(repl)
+			continue
+		}
+		for j := 0; j < rlen; j++ {
+			om = append(om, i+j)
+		}
+		out.WriteString(s[i : i+rlen])
+	}
+	om = append(om, len(s))
+	return out.String(), om
+}
+
+// digitValue returns the value of a unicode decimal digit.
+// Digits are encoded in contiguous runs, each starting at zero.
+func digitValue(r rune) rune {
+	zero := r
+	for unicode.IsDigit(zero - 1) {
+		zero--
+	}
+	return (r - zero) % 10
+}
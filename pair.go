@@ -0,0 +1,225 @@
+package fuzzytime
+
+import (
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// DefaultPairDistance is the furthest apart (in characters) a date and a
+// time can be and still be paired up, if the Context doesn't say otherwise
+const DefaultPairDistance = 40
+
+// sentenceBreakRE matches the end of a sentence, or a blank line
+var sentenceBreakRE = regexp.MustCompile(`[.!?](?:[\s\p{Z}]|$)|[。！？]|\n[\s\p{Z}]*\n`)
+
+// Pairing holds the dates and times found in a string, paired up where
+// they belong together.
+type Pairing struct {
+	// Pairs holds the dates which have a time nearby, combined into a
+	// single match
+	Pairs []Match
+	// Dates and Times hold the dates and times left over
+	Dates []Match
+	Times []Match
+}
+
+// ExtractPairs finds all the dates and times in a string, and pairs up
+// each time with the nearest date (and vice versa). Only full dates are
+// paired with times, and then only if they're within the Context's
+// PairDistance of each other and not in different sentences. Dates and
+// times without a partner are returned separately.
+// For example, "Updated 14:02. Originally published 3 March 2009 at
+// 09:15" gives 2009-03-03T09:15 as a pair, with 14:02 left over.
+// All the matches are in order of position. If an error occurs, the
+// dates and times found before it are returned along with the error.
+func (ctx *Context) ExtractPairs(s string) (Pairing, error) {
+	var out Pairing
+	times := ctx.allTimes(s)
+	dates, err := ctx.allDates(s, times)
+
+	// every possible pair, closest first
+	type pair struct{ d, t, gap int }
+	var pairs []pair
+	for di := range dates {
+		for ti := range times {
+			if gap, ok := ctx.pairable(s, &dates[di], &times[ti]); ok {
+				pairs = append(pairs, pair{di, ti, gap})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].gap < pairs[j].gap })
+
+	datePaired := make([]bool, len(dates))
+	timePaired := make([]bool, len(times))
+	for _, p := range pairs {
+		if datePaired[p.d] || timePaired[p.t] {
+			continue
+		}
+		datePaired[p.d], timePaired[p.t] = true, true
+		m := dates[p.d]
+		m.DateTime.Time = times[p.t].DateTime.Time
+		m.timeSpan = times[p.t].timeSpan
+		m.Spans = tidySpans(append(append([]Span{}, m.Spans...), times[p.t].Spans...))
+		m.Fields.merge(&times[p.t].Fields)
+		out.Pairs = append(out.Pairs, m)
+	}
+	for i := range dates {
+		if !datePaired[i] {
+			out.Dates = append(out.Dates, dates[i])
+		}
+	}
+	for i := range times {
+		if !timePaired[i] {
+			out.Times = append(out.Times, times[i])
+		}
+	}
+	sortMatches(out.Pairs)
+	sortMatches(out.Dates)
+	sortMatches(out.Times)
+	return out, err
+}
+
+// ExtractPairs finds all the dates and times in a string, and pairs them up.
+// Equivalent to DefaultContext.ExtractPairs()
+func ExtractPairs(s string) (Pairing, error) { return DefaultContext.ExtractPairs(s) }
+
+// allTimes finds all the times in a string, in order of position
+func (ctx *Context) allTimes(s string) []Match {
+	matches, _ := searchAll([]byte(s), 0, len(s), windowMargin, ctx.findTime)
+	return matches
+}
+
+// findTime finds the best time in s, for allTimes
+func (ctx *Context) findTime(s string) (Match, error) {
+	norm, om := normalise(s)
+	ft, span, fields, _ := ctx.extractTime(norm)
+	if ft.Empty() {
+		return Match{}, nil
+	}
+	span = om.span(span)
+	fields.remap(om)
+	return Match{DateTime: DateTime{Time: ft}, Spans: []Span{span}, Fields: fields, timeSpan: span}, nil
+}
+
+// allDates finds all the dates in a string, in order of position.
+// The times are masked out first, so their digits aren't mistaken for
+// parts of dates.
+func (ctx *Context) allDates(s string, times []Match) ([]Match, error) {
+	buf := []byte(s)
+	for i := range times {
+		blank(buf, times[i].Spans[:1])
+	}
+	return searchAll(buf, 0, len(s), windowMargin, ctx.findDate)
+}
+
+// findDate finds the best date in s, for allDates
+func (ctx *Context) findDate(s string) (Match, error) {
+	fd, span, fields, err := ctx.extractDateDetailed(s)
+	if err != nil {
+		return Match{Spans: []Span{span}}, err
+	}
+	return Match{DateTime: DateTime{Date: fd}, Spans: []Span{span}, Fields: fields, dateSpan: span}, nil
+}
+
+// pairable checks if a date and a time belong together, returning the
+// gap between them. The date must be complete, and the two must be
+// within PairDistance of each other with no sentence break in between.
+func (ctx *Context) pairable(s string, date *Match, tm *Match) (int, bool) {
+	fd := &date.DateTime.Date
+	if !fd.HasYear() || !fd.HasMonth() || !fd.HasDay() {
+		return 0, false
+	}
+	return ctx.near(s, coreDateSpan(date.Spans[0], &date.Fields), coreTimeSpan(tm.Spans[0], &tm.Fields))
+}
+
+// pairDistance returns the PairDistance, or the default if it's unset
+func (ctx *Context) pairDistance() int {
+	if ctx.PairDistance == 0 {
+		return DefaultPairDistance
+	}
+	return ctx.PairDistance
+}
+
+// near checks if a date span and a time span are close enough to be
+// paired, returning the gap between them (in characters)
+func (ctx *Context) near(s string, dateSpan Span, timeSpan Span) (int, bool) {
+	maxGap := ctx.pairDistance()
+	gap := spanGap(dateSpan, timeSpan)
+	if gap == 0 {
+		return 0, true
+	}
+	if gap > utf8.UTFMax*maxGap {
+		// too far apart, however many bytes the characters take
+		return gap, false
+	}
+	first, second := dateSpan, timeSpan
+	if first.Begin > second.Begin {
+		first, second = second, first
+	}
+	between := s[first.End:second.Begin]
+	gap = utf8.RuneCountInString(between)
+	if gap > maxGap || sentenceBreakRE.MatchString(between) {
+		return gap, false
+	}
+	return gap, true
+}
+
+// coreTimeSpan returns the span of a time, trimmed to the fields it was
+// parsed from (the crackers can take in a trailing character)
+func coreTimeSpan(span Span, f *Fields) Span {
+	return coreSpan(span, &f.Hour, &f.Minute, &f.Second, &f.Fractional, &f.AMPM, &f.TZ)
+}
+
+// coreDateSpan returns the span of a date, trimmed to the year, month and
+// day (the weekday crackers can take in a preceding word)
+func coreDateSpan(span Span, f *Fields) Span {
+	return coreSpan(span, &f.Year, &f.Month, &f.Day)
+}
+
+// coreSpan returns the span covering the given fields, or the whole span
+// if none are set
+func coreSpan(span Span, fields ...*FieldSpan) Span {
+	core := Span{Begin: -1}
+	for _, fs := range fields {
+		if fs.Begin == fs.End {
+			continue
+		}
+		if core.Begin < 0 || fs.Begin < core.Begin {
+			core.Begin = fs.Begin
+		}
+		if fs.End > core.End {
+			core.End = fs.End
+		}
+	}
+	if core.Begin < 0 {
+		return span
+	}
+	return core
+}
+
+// attachNearestTime looks for the time nearest to the date in m, and adds
+// it to the match. Times overlapping the date are ignored.
+func (ctx *Context) attachNearestTime(s string, m *Match) {
+	dateSpan := coreDateSpan(m.Span(), &m.Fields)
+	var best *Match
+	bestGap := 0
+	times := ctx.allTimes(s)
+	for i := range times {
+		tm := &times[i]
+		if overlaps(tm.Spans[0], dateSpan) {
+			continue
+		}
+		gap, ok := ctx.near(s, dateSpan, coreTimeSpan(tm.Spans[0], &tm.Fields))
+		if ok && (best == nil || gap < bestGap) {
+			best, bestGap = tm, gap
+		}
+	}
+	if best == nil {
+		return
+	}
+	m.DateTime.Time = best.DateTime.Time
+	m.timeSpan = best.timeSpan
+	m.Spans = tidySpans(append(append([]Span{}, m.Spans...), best.Spans...))
+	m.Fields.merge(&best.Fields)
+}
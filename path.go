@@ -0,0 +1,133 @@
+package fuzzytime
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pathCrackers are regexps for dates embedded in URLs and file names.
+// They're tried in order, so the more complete forms come first.
+// Digit runs must be bounded by non-digits, so the matches include a
+// character either side (the spans are taken from the groups).
+var pathCrackers = []*regexp.Regexp{
+	// "IMG_20140410_153000.jpg", "20140410T1530", "backup-2014-04-10T1530.tar.gz"
+	regexp.MustCompile(`(?:^|[^0-9])(?P<year>\d{4})(?P<sep>[-_.]?)(?P<month>\d{2})(?P<sep2>[-_.]?)(?P<day>\d{2})[T_-](?P<hour>\d{2})[:.-]?(?P<minute>\d{2})(?:[:.-]?(?P<second>\d{2}))?(?:[^0-9]|$)`),
+
+	// "/2014/04/10/some-slug", "/2014/apr/10/", "/2014/04/"
+	regexp.MustCompile(`(?i)(?:^|/)(?P<year>\d{4})/(?P<month>\d{1,2}|[a-z]{3,9})(?:/(?P<day>\d{1,2}))?(?:/|$)`),
+
+	// "2014-04-10-slug", "2014_04_10", "2014.04.10"
+	regexp.MustCompile(`(?:^|[^0-9])(?P<year>\d{4})(?P<sep>[-_.])(?P<month>\d{2})(?P<sep2>[-_.])(?P<day>\d{2})(?:[^0-9]|$)`),
+
+	// "/news/20140410-slug.html"
+	regexp.MustCompile(`(?:^|[^0-9])(?P<year>\d{4})(?P<month>\d{2})(?P<day>\d{2})(?:[^0-9]|$)`),
+}
+
+// plausible year range for dates in paths (to avoid picking up IDs and
+// other numbers)
+const (
+	minPathYear = 1900
+	maxPathYear = 2099
+)
+
+// ExtractPath tries to parse a date and time from a URL or file name.
+// Equivalent to DefaultContext.ExtractPath()
+func ExtractPath(s string) (DateTime, []Span, error) { return DefaultContext.ExtractPath(s) }
+
+// ExtractPath tries to parse a date and time from a URL or file name, eg
+// "/2014/04/10/some-slug", "/news/20140410-slug.html",
+// "IMG_20140410_153000.jpg" or "backup-2014-04-10T1530.tar.gz".
+// Unlike Extract, it handles compact forms with no separators and
+// year/month/day path segments, and it checks the values are plausible
+// (eg a year between 1900 and 2099, a day which exists in the month) to
+// avoid picking up IDs and other numbers.
+// If none found, the returned DateTime will be empty.
+func (ctx *Context) ExtractPath(s string) (DateTime, []Span, error) {
+	for _, pat := range pathCrackers {
+		names := pat.SubexpNames()
+		for _, m := range pat.FindAllStringSubmatchIndex(s, -1) {
+			var dt DateTime
+			var seps []string
+			fail := false
+			span := Span{Begin: -1}
+			for i, name := range names {
+				start, end := m[i*2], m[i*2+1]
+				if i == 0 || start < 0 {
+					continue
+				}
+				if span.Begin < 0 {
+					span.Begin = start
+				}
+				span.End = end
+				sub := s[start:end]
+				switch name {
+				case "sep", "sep2":
+					seps = append(seps, sub)
+				case "month":
+					if month, ok := monthLookup[strings.ToLower(sub)]; ok {
+						dt.SetMonth(month)
+						continue
+					}
+					fallthrough
+				default:
+					v, err := strconv.Atoi(sub)
+					if err != nil {
+						fail = true
+						break
+					}
+					setPathField(&dt, name, v)
+				}
+			}
+			// separators must be consistent ("2014-04_10" is suspicious)
+			if len(seps) == 2 && seps[0] != seps[1] {
+				fail = true
+			}
+			if !fail && dt.plausible() {
+				return dt, []Span{span}, nil
+			}
+		}
+	}
+	return DateTime{}, []Span{}, nil
+}
+
+// setPathField sets a field by name
+func setPathField(dt *DateTime, name string, v int) {
+	switch name {
+	case "year":
+		dt.SetYear(v)
+	case "month":
+		dt.SetMonth(v)
+	case "day":
+		dt.SetDay(v)
+	case "hour":
+		dt.SetHour(v)
+	case "minute":
+		dt.SetMinute(v)
+	case "second":
+		dt.SetSecond(v)
+	}
+}
+
+// plausible checks the fields of a datetime found in a path
+func (dt *DateTime) plausible() bool {
+	if dt.Year() < minPathYear || dt.Year() > maxPathYear {
+		return false
+	}
+	if dt.Month() < 1 || dt.Month() > 12 {
+		return false
+	}
+	if dt.HasDay() && (dt.Day() < 1 || dt.Day() > daysInMonth(dt.Year(), dt.Month())) {
+		return false
+	}
+	if dt.HasHour() && dt.Hour() > 23 {
+		return false
+	}
+	if dt.HasMinute() && dt.Minute() > 59 {
+		return false
+	}
+	if dt.HasSecond() && dt.Second() > 59 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package fuzzytime
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Period is a part of a year (or a run of years) which a Date can refer
+// to, for approximate dates like "spring 2009", "Q3 2011" or "the 1980s".
+// The Date's year field holds the year the period starts in (so for
+// decades and centuries, the first year: 1980 or 1800).
+type Period int
+
+const (
+	NoPeriod Period = iota
+	Spring          // March to May (northern hemisphere)
+	Summer          // June to August
+	Autumn          // September to November
+	Winter          // December to the following February
+	Q1              // first quarter: January to March
+	Q2              // April to June
+	Q3              // July to September
+	Q4              // October to December
+	H1              // first half: January to June
+	H2              // July to December
+	Decade          // ten years, starting with the year field
+	Century         // a hundred years, starting with the year field
+)
+
+var periodNames = []string{"", "spring", "summer", "autumn", "winter",
+	"Q1", "Q2", "Q3", "Q4", "H1", "H2", "decade", "century"}
+
+// String returns the name of the period
+func (p Period) String() string {
+	if p >= 0 && int(p) < len(periodNames) {
+		return periodNames[p]
+	}
+	return fmt.Sprintf("Period(%d)", int(p))
+}
+
+// Qualifier narrows a Date to part of its range, as in "early 2010",
+// "mid-March" or "the late 1990s". Each covers about a third.
+type Qualifier int
+
+const (
+	NoQualifier Qualifier = iota
+	Early
+	Mid
+	Late
+)
+
+// String returns the qualifier as a word
+func (q Qualifier) String() string {
+	switch q {
+	case NoQualifier:
+		return ""
+	case Early:
+		return "early"
+	case Mid:
+		return "mid"
+	case Late:
+		return "late"
+	}
+	return fmt.Sprintf("Qualifier(%d)", int(q))
+}
+
+// units for intervals
+const (
+	yearUnit = iota
+	monthUnit
+	dayUnit
+)
+
+// Range returns the first and last days covered by the date, taking
+// into account periods, qualifiers and circa. For example
+// "2010-05" gives 2010-05-01 to 2010-05-31, "spring 2009" gives
+// 2009-03-01 to 2009-05-31 and "late 1990s" gives 1997-01-01 to
+// 1999-12-31.
+// A circa date is widened by its own length on either side (so
+// "circa 1850" covers 1849 to 1851).
+// An error is returned if the year is unset.
+func (d *Date) Range() (first Date, last Date, err error) {
+	if !d.HasYear() {
+		return Date{}, Date{}, errors.New("date has no year")
+	}
+
+	// work out the interval as a number of units
+	var unit, start, count int
+	switch d.Period() {
+	case Decade:
+		unit, start, count = yearUnit, d.Year(), 10
+	case Century:
+		unit, start, count = yearUnit, d.Year(), 100
+	case Spring, Summer, Autumn, Winter:
+		unit, start, count = monthUnit, d.Year()*12+2+3*int(d.Period()-Spring), 3
+	case Q1, Q2, Q3, Q4:
+		unit, start, count = monthUnit, d.Year()*12+3*int(d.Period()-Q1), 3
+	case H1, H2:
+		unit, start, count = monthUnit, d.Year()*12+6*int(d.Period()-H1), 6
+	default:
+		switch {
+		case d.HasMonth() && d.HasDay():
+			unit, start, count = dayUnit, fixedFromGregorian(d.Year(), d.Month(), d.Day()), 1
+		case d.HasMonth():
+			// use days, so the month can be split up by a qualifier
+			unit, start = dayUnit, fixedFromGregorian(d.Year(), d.Month(), 1)
+			count = daysInMonth(d.Year(), d.Month())
+		default:
+			unit, start, count = monthUnit, d.Year()*12, 12
+		}
+	}
+
+	// early/mid/late take a third each
+	switch d.Qualifier() {
+	case Early:
+		count = (count + 1) / 3
+	case Mid:
+		a, b := (count+1)/3, (2*count+1)/3
+		start, count = start+a, b-a
+	case Late:
+		a := (2*count + 1) / 3
+		start, count = start+a, count-a
+	}
+
+	if d.Circa() {
+		start -= count
+		count *= 3
+	}
+
+	switch unit {
+	case yearUnit:
+		first = *NewDate(start, 1, 1)
+		last = *NewDate(start+count-1, 12, 31)
+	case monthUnit:
+		first = *NewDate(fdiv(start, 12), fmod(start, 12)+1, 1)
+		end := start + count - 1
+		last = *NewDate(fdiv(end, 12), fmod(end, 12)+1, daysInMonth(fdiv(end, 12), fmod(end, 12)+1))
+	case dayUnit:
+		first = dateFromFixed(start)
+		last = dateFromFixed(start + count - 1)
+	}
+	return first, last, nil
+}
+
+// daysInMonth returns the number of days in a (gregorian) month
+func daysInMonth(year, month int) int {
+	return fixedFromGregorian(year, month+1, 1) - fixedFromGregorian(year, month, 1)
+}
@@ -0,0 +1,378 @@
+/*
+Package pubdate finds the publication and modification dates of articles
+in HTML documents.
+
+It gathers candidate dates from the places news sites tend to put them:
+
+  - <meta> tags (article:published_time, og:updated_time, dc.date,
+    sailthru.date, parsely-pub-date, parsely-page etc)
+  - <time datetime="..."> elements, and elements with itemprop
+    datePublished/dateModified
+  - JSON-LD blocks (datePublished, dateCreated, dateModified)
+  - bylines and datelines
+  - the article URL
+
+Each candidate is parsed with fuzzytime and scored according to where it
+came from and how precise it is. The best-scoring candidates become the
+publication and modification dates, and every candidate is returned along
+with its provenance, for debugging or for callers with their own ideas.
+
+	res := pubdate.Extract(&fuzzytime.WesternContext, doc, "http://example.com/2014/04/10/story")
+	if res.Published != nil {
+	    fmt.Println(res.Published.ISOFormat(), res.Published.Source, res.Published.Detail)
+	}
+*/
+package pubdate
+
+import (
+	"encoding/json"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bcampbell/fuzzytime"
+)
+
+// Kind says which date a candidate is for
+type Kind int
+
+const (
+	Published Kind = iota
+	Modified
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	if k == Modified {
+		return "modified"
+	}
+	return "published"
+}
+
+// Source identifies the part of the document a candidate came from
+type Source int
+
+const (
+	MetaTag  Source = iota // <meta> tag
+	TimeTag                // <time> element
+	ItemProp               // element with an itemprop attribute
+	JSONLD                 // JSON-LD block
+	Byline                 // byline or dateline text
+	URL                    // the article URL
+)
+
+var sourceNames = []string{"meta", "time", "itemprop", "json-ld", "byline", "url"}
+
+// String returns a short name for the source
+func (s Source) String() string {
+	if s >= 0 && int(s) < len(sourceNames) {
+		return sourceNames[s]
+	}
+	return "unknown"
+}
+
+// Candidate is a date found in the document
+type Candidate struct {
+	fuzzytime.DateTime
+	Kind   Kind
+	Source Source
+	// Detail says more about the source, eg the meta tag name
+	// ("article:published_time") or JSON-LD key ("datePublished")
+	Detail string
+	// Text is the raw text the date was parsed from
+	Text string
+	// Score is used to rank the candidates - higher is better
+	Score int
+}
+
+// Result holds the dates found in a document
+type Result struct {
+	// Published and Modified are the best candidates for each date,
+	// or nil if none were found
+	Published *Candidate
+	Modified  *Candidate
+	// Candidates holds all the candidates, best first
+	Candidates []Candidate
+}
+
+// metaKey describes a known meta tag (or itemprop) name
+type metaKey struct {
+	kind  Kind
+	score int
+}
+
+// metaKeys lists the meta tag names we know about, lowercased.
+// These are matched against the name, property and itemprop attributes.
+var metaKeys = map[string]metaKey{
+	"article:published_time":    {Published, 10},
+	"og:published_time":         {Published, 9},
+	"og:article:published_time": {Published, 9},
+	"datepublished":             {Published, 9},
+	"parsely-pub-date":          {Published, 8},
+	"dc.date.issued":            {Published, 8},
+	"dcterms.issued":            {Published, 8},
+	"citation_publication_date": {Published, 7},
+	"sailthru.date":             {Published, 7},
+	"pubdate":                   {Published, 7},
+	"publishdate":               {Published, 7},
+	"publish-date":              {Published, 7},
+	"dcterms.created":           {Published, 7},
+	"dc.date.created":           {Published, 7},
+	"datecreated":               {Published, 6},
+	"dc.date":                   {Published, 6},
+	"dcterms.date":              {Published, 6},
+	"date":                      {Published, 5},
+
+	"article:modified_time":  {Modified, 10},
+	"og:updated_time":        {Modified, 9},
+	"datemodified":           {Modified, 9},
+	"dc.date.modified":       {Modified, 8},
+	"dcterms.modified":       {Modified, 8},
+	"last-modified":          {Modified, 6},
+	"article:updated_time":   {Modified, 9},
+	"sailthru.lastmodified":  {Modified, 7},
+	"parsely-updated-date":   {Modified, 8},
+	"citation_date_modified": {Modified, 7},
+}
+
+// base scores for the other sources
+const (
+	jsonLDScore      = 9
+	createdPenalty   = 1 // JSON-LD dateCreated is less likely to be right
+	timeTagScore     = 5
+	timePubdateScore = 8 // <time pubdate>
+	bylineScore      = 4
+	urlScore         = 3
+	parselyScore     = 8
+)
+
+var (
+	tagRE      = regexp.MustCompile(`(?is)<([a-z][a-z0-9]*)\b([^>]*)>`)
+	attrRE     = regexp.MustCompile(`(?is)([a-z_:][-a-z0-9_:.]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+	jsonLDRE   = regexp.MustCompile(`(?is)<script\b[^>]*type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+	bylineRE   = regexp.MustCompile(`(?is)<([a-z][a-z0-9]*)\b[^>]*class\s*=\s*["'][^"']*\b(byline|dateline|pubdate|published|updated|timestamp|date)\b[^"']*["'][^>]*>`)
+	stripRE    = regexp.MustCompile(`(?s)<[^>]*>`)
+	modifiedRE = regexp.MustCompile(`(?i)\b(updated|modified|last changed)\b`)
+)
+
+// Extract looks for publication and modification dates in an HTML
+// document. pageURL is the address of the document (or "" if unknown).
+// A nil ctx means fuzzytime.DefaultContext.
+func Extract(ctx *fuzzytime.Context, doc string, pageURL string) Result {
+	if ctx == nil {
+		ctx = &fuzzytime.DefaultContext
+	}
+	var cands []Candidate
+	add := func(kind Kind, source Source, detail string, text string, score int) {
+		text = strings.TrimSpace(html.UnescapeString(text))
+		if text == "" {
+			return
+		}
+		extract := ctx.Extract
+		if source == URL {
+			extract = ctx.ExtractPath
+		}
+		dt, _, err := extract(text)
+		if err != nil || !dt.HasYear() || !dt.HasMonth() {
+			return
+		}
+		cands = append(cands, Candidate{
+			DateTime: dt,
+			Kind:     kind,
+			Source:   source,
+			Detail:   detail,
+			Text:     text,
+			Score:    score + precisionBonus(&dt),
+		})
+	}
+
+	// meta tags, <time> and itemprops
+	for _, m := range tagRE.FindAllStringSubmatch(doc, -1) {
+		tag := strings.ToLower(m[1])
+		attrs := parseAttrs(m[2])
+		switch {
+		case tag == "meta":
+			key := attrs["property"]
+			if key == "" {
+				key = attrs["name"]
+			}
+			if key == "" {
+				key = attrs["itemprop"]
+			}
+			key = strings.ToLower(key)
+			if key == "parsely-page" {
+				var page map[string]interface{}
+				if json.Unmarshal([]byte(html.UnescapeString(attrs["content"])), &page) == nil {
+					if s, ok := page["pub_date"].(string); ok {
+						add(Published, MetaTag, "parsely-page", s, parselyScore)
+					}
+				}
+				continue
+			}
+			if mk, ok := metaKeys[key]; ok {
+				add(mk.kind, MetaTag, key, attrs["content"], mk.score)
+			}
+		case attrs["itemprop"] != "":
+			key := strings.ToLower(attrs["itemprop"])
+			mk, ok := metaKeys[key]
+			if !ok {
+				continue
+			}
+			val := attrs["datetime"]
+			if val == "" {
+				val = attrs["content"]
+			}
+			add(mk.kind, ItemProp, attrs["itemprop"], val, mk.score)
+		case tag == "time":
+			score := timeTagScore
+			if _, ok := attrs["pubdate"]; ok {
+				score = timePubdateScore
+			}
+			kind := Published
+			if modifiedRE.MatchString(attrs["class"]) {
+				kind = Modified
+			}
+			add(kind, TimeTag, "datetime", attrs["datetime"], score)
+		}
+	}
+
+	// JSON-LD
+	for _, m := range jsonLDRE.FindAllStringSubmatch(doc, -1) {
+		var data interface{}
+		if json.Unmarshal([]byte(strings.TrimSpace(m[1])), &data) != nil {
+			continue
+		}
+		walkJSON(data, func(key string, val string) {
+			switch key {
+			case "datePublished":
+				add(Published, JSONLD, key, val, jsonLDScore)
+			case "dateCreated":
+				add(Published, JSONLD, key, val, jsonLDScore-createdPenalty)
+			case "dateModified":
+				add(Modified, JSONLD, key, val, jsonLDScore)
+			}
+		})
+	}
+
+	// bylines and datelines
+	closeREs := map[string]*regexp.Regexp{} // closing tag patterns, by tag name
+	for _, m := range bylineRE.FindAllStringSubmatchIndex(doc, -1) {
+		// text runs up to the closing tag (nested elements of the same
+		// type will cut it short, but that's OK for bylines)
+		start := m[1]
+		tag := strings.ToLower(doc[m[2]:m[3]])
+		closeRE, ok := closeREs[tag]
+		if !ok {
+			closeRE = regexp.MustCompile(`(?i)</` + regexp.QuoteMeta(tag) + `\s*>`)
+			closeREs[tag] = closeRE
+		}
+		loc := closeRE.FindStringIndex(doc[start:])
+		if loc == nil {
+			continue
+		}
+		text := strings.Join(strings.Fields(stripRE.ReplaceAllString(doc[start:start+loc[0]], " ")), " ")
+		class := strings.ToLower(doc[m[4]:m[5]])
+		if class == "updated" {
+			add(Modified, Byline, class, text, bylineScore)
+			continue
+		}
+		// "Published 10 March, updated 11 March"
+		if loc := modifiedRE.FindStringIndex(text); loc != nil {
+			add(Published, Byline, class, text[:loc[0]], bylineScore)
+			add(Modified, Byline, class, text[loc[0]:], bylineScore)
+			continue
+		}
+		add(Published, Byline, class, text, bylineScore)
+	}
+
+	// URL
+	if pageURL != "" {
+		if u, err := url.Parse(pageURL); err == nil {
+			add(Published, URL, "path", u.Path, urlScore)
+		}
+	}
+
+	return rank(cands)
+}
+
+// precisionBonus favours more precise dates
+func precisionBonus(dt *fuzzytime.DateTime) int {
+	bonus := 0
+	if dt.HasDay() {
+		bonus++
+	}
+	if dt.HasHour() {
+		bonus++
+	}
+	if dt.HasTZOffset() {
+		bonus++
+	}
+	return bonus
+}
+
+// rank scores up candidates which agree with other sources, sorts them
+// and picks out the best of each kind
+func rank(cands []Candidate) Result {
+	for i := range cands {
+		agree := map[Source]bool{}
+		for j := range cands {
+			if i == j || cands[j].Source == cands[i].Source || cands[j].Kind != cands[i].Kind {
+				continue
+			}
+			if !cands[i].Date.Conflicts(&cands[j].Date) {
+				agree[cands[j].Source] = true
+			}
+		}
+		cands[i].Score += len(agree)
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].Score > cands[j].Score })
+
+	res := Result{Candidates: cands}
+	for i := range cands {
+		c := &cands[i]
+		if c.Kind == Published && res.Published == nil {
+			res.Published = c
+		}
+		if c.Kind == Modified && res.Modified == nil {
+			res.Modified = c
+		}
+	}
+	return res
+}
+
+// parseAttrs returns the attributes of a tag, keyed by lowercased name
+func parseAttrs(s string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range attrRE.FindAllStringSubmatch(s, -1) {
+		attrs[strings.ToLower(m[1])] = m[2] + m[3] + m[4]
+	}
+	return attrs
+}
+
+// walkJSON calls fn for every string value in a decoded JSON structure,
+// with the key it was stored under
+func walkJSON(data interface{}, fn func(key string, val string)) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		// sort the keys, so the output is the same every time
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			val := v[key]
+			if s, ok := val.(string); ok {
+				fn(key, s)
+			} else {
+				walkJSON(val, fn)
+			}
+		}
+	case []interface{}:
+		for _, val := range v {
+			walkJSON(val, fn)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pubdate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bcampbell/fuzzytime"
+)
+
+func TestExtract(t *testing.T) {
+	testData := []struct {
+		name      string
+		doc       string
+		url       string
+		published string
+		source    Source
+		modified  string
+	}{
+		{
+			"meta tags",
+			`<html><head>
+<meta property="article:published_time" content="2014-04-10T09:30:00+01:00" />
+<meta property="article:modified_time" content="2014-04-11T10:00:00+01:00" />
+<meta name="dc.date" content="2014-04-10">
+</head><body></body></html>`,
+			"", "2014-04-10T09:30:00+01:00", MetaTag, "2014-04-11T10:00:00+01:00",
+		},
+		{
+			"json-ld",
+			`<script type="application/ld+json">
+{"@context": "http://schema.org", "@type": "NewsArticle",
+ "headline": "Something happened",
+ "datePublished": "2015-06-01T12:00:00Z", "dateModified": "2015-06-02T08:15:00Z"}
+</script>`,
+			"", "2015-06-01T12:00:00Z", JSONLD, "2015-06-02T08:15:00Z",
+		},
+		{
+			"time element",
+			`<article><h1>Headline</h1><time datetime="2013-02-14T18:00Z" pubdate>14 Feb</time>
+<p>Body text, mentioning 1 January 2001.</p></article>`,
+			"", "2013-02-14T18:00Z", TimeTag, "",
+		},
+		{
+			"itemprop",
+			`<span itemprop="datePublished" content="2012-08-20">Aug 20</span>`,
+			"", "2012-08-20", ItemProp, "",
+		},
+		{
+			"parsely",
+			`<meta name="parsely-page" content="{&quot;title&quot;: &quot;Story&quot;, &quot;pub_date&quot;: &quot;2016-03-05T10:00:00Z&quot;}">`,
+			"", "2016-03-05T10:00:00Z", MetaTag, "",
+		},
+		{
+			"byline",
+			`<div class="article-byline">By Brian Credability <span>Published 10 March 1999</span><br>Updated 11 March 1999</div>`,
+			"", "1999-03-10", Byline, "1999-03-11",
+		},
+		{
+			"byline and url",
+			`<p class="byline">By Ann Other, Updated 5 May 2011 14:00</p>`,
+			"http://example.com/news/2011/05/04/story.html", "2011-05-04", URL, "2011-05-05T14:00",
+		},
+		{
+			// case-changing text before the byline ("\u212a" lowercases
+			// to a shorter "k", and "\xe9" isn't valid UTF-8)
+			"byline after non-ascii",
+			"<p>" + strings.Repeat("\u212a", 50) + " caf\xe9</p><SPAN class=\"byline\">Posted 3 June 2008</SPAN >",
+			"", "2008-06-03", Byline, "",
+		},
+		{
+			"compact url",
+			`<html><body><p>Nothing in the page.</p></body></html>`,
+			"http://example.com/news/20140410-slug.html", "2014-04-10", URL, "",
+		},
+		{
+			"nothing",
+			`<html><body><p>No dates here.</p></body></html>`,
+			"http://example.com/about", "", 0, "",
+		},
+	}
+
+	for _, dat := range testData {
+		res := Extract(&fuzzytime.WesternContext, dat.doc, dat.url)
+		got := ""
+		if res.Published != nil {
+			got = res.Published.ISOFormat()
+			if res.Published.Source != dat.source {
+				t.Errorf("%s: expected published source %s, but got %s", dat.name, dat.source, res.Published.Source)
+			}
+		}
+		if got != dat.published {
+			t.Errorf("%s: expected published %q, but got %q", dat.name, dat.published, got)
+		}
+		got = ""
+		if res.Modified != nil {
+			got = res.Modified.ISOFormat()
+		}
+		if got != dat.modified {
+			t.Errorf("%s: expected modified %q, but got %q", dat.name, dat.modified, got)
+		}
+	}
+}
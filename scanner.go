@@ -0,0 +1,192 @@
+package fuzzytime
+
+import "io"
+
+// default Scanner buffer sizes
+const (
+	defaultScanWindow  = 1024
+	defaultScanOverlap = 128
+)
+
+// maxEmptyReads is the number of reads returning no data in a row before
+// the Scanner gives up (as for bufio.Scanner)
+const maxEmptyReads = 100
+
+// Scanner extracts datetimes from an io.Reader, without having to load
+// the whole input into memory. It works like bufio.Scanner:
+//
+//	sc := ctx.NewScanner(r)
+//	for sc.Scan() {
+//	    m := sc.Match()
+//	    fmt.Println(m.DateTime.ISOFormat(), m.Spans)
+//	}
+//	if err := sc.Err(); err != nil {
+//	    ...
+//	}
+//
+// The input is read into a fixed-size window, which is searched in the
+// same way as ExtractAll. Successive windows overlap, and start at the
+// beginning of a word where possible, so a datetime which straddles the
+// end of one window is picked up whole from the next. Matches are
+// returned in order of position, and their spans (and fields) are
+// absolute byte offsets into the whole input.
+//
+// Note that matches must fit within the overlap to be found reliably
+// (a date and time further apart than that might not be paired up).
+//
+// Scanning stops at the first error, either from the reader or from
+// extraction (eg an ambiguous date, which the Context can't resolve).
+// The matches before the error are returned first.
+type Scanner struct {
+	ctx     *Context
+	r       io.Reader
+	window  int
+	overlap int
+
+	buf  []byte // the current window, with the matches found blanked out
+	base int    // offset of buf[0] in the input
+	from int    // offset in buf of the text not yet searched
+	eof  bool
+
+	pending []Match
+	match   Match
+	err     error
+}
+
+// NewScanner returns a Scanner to extract datetimes from r.
+// Equivalent to DefaultContext.NewScanner()
+func NewScanner(r io.Reader) *Scanner { return DefaultContext.NewScanner(r) }
+
+// NewScanner returns a Scanner to extract datetimes from r, using this
+// context.
+func (ctx *Context) NewScanner(r io.Reader) *Scanner {
+	return &Scanner{
+		ctx:     ctx,
+		r:       r,
+		window:  defaultScanWindow,
+		overlap: defaultScanOverlap,
+	}
+}
+
+// Buffer sets the size of the window the input is read into, and the
+// amount successive windows overlap by (the defaults are 1024 and 128
+// bytes). Each window moves on by window-2*overlap bytes, so the window
+// must be more than twice the overlap.
+// Buffer panics if called after scanning has started.
+func (sc *Scanner) Buffer(window int, overlap int) {
+	if sc.buf != nil {
+		panic("Buffer called after Scan")
+	}
+	if overlap < 0 || window <= 2*overlap {
+		panic("window must be more than twice the overlap")
+	}
+	sc.window, sc.overlap = window, overlap
+}
+
+// Scan advances to the next match, which is then available via Match.
+// It returns false when there are no more matches, either because the end
+// of the input was reached or because of an error (see Err).
+func (sc *Scanner) Scan() bool {
+	for len(sc.pending) == 0 {
+		if sc.err != nil || (sc.eof && sc.buf == nil) {
+			return false
+		}
+		sc.fill()
+	}
+	sc.match = sc.pending[0]
+	sc.pending = sc.pending[1:]
+	return true
+}
+
+// Match returns the most recent match found by Scan
+func (sc *Scanner) Match() Match {
+	return sc.match
+}
+
+// Err returns the first error encountered (io.EOF isn't an error)
+func (sc *Scanner) Err() error {
+	return sc.err
+}
+
+// fill reads the next window and extracts matches from it
+func (sc *Scanner) fill() {
+	if sc.buf == nil {
+		sc.buf = make([]byte, 0, sc.window)
+	}
+	var readErr error
+	for empty := 0; len(sc.buf) < sc.window && !sc.eof && readErr == nil; {
+		n, err := sc.r.Read(sc.buf[len(sc.buf):sc.window])
+		sc.buf = sc.buf[:len(sc.buf)+n]
+		switch {
+		case err == io.EOF:
+			sc.eof = true
+		case err != nil:
+			// search what was read before reporting it
+			readErr = err
+		case n > 0:
+			empty = 0
+		default:
+			// guard against readers which never return anything
+			empty++
+			if empty >= maxEmptyReads {
+				readErr = io.ErrNoProgress
+			}
+		}
+	}
+	last := sc.eof || readErr != nil
+
+	// matches beginning in the overlap are left for the next window
+	limit := len(sc.buf)
+	if !last {
+		limit = wordStart(string(sc.buf), limit-sc.overlap, sc.overlap/4)
+	}
+
+	matches, err := searchAll(sc.buf, sc.from, limit, windowMargin+sc.ctx.pairDistance(), sc.ctx.extract)
+	for i := range matches {
+		matches[i].shift(sc.base)
+	}
+	sc.pending = append(sc.pending, matches...)
+	if err == nil {
+		err = readErr
+	}
+	if err != nil {
+		sc.err = err
+		return
+	}
+	if last {
+		sc.buf = nil
+		return
+	}
+
+	// keep the overlap for the next window, along with the same amount
+	// again before it, for context
+	keep := wordStart(string(sc.buf), limit-sc.overlap, sc.overlap/4)
+	if keep > limit {
+		keep = limit
+	}
+	n := copy(sc.buf, sc.buf[keep:])
+	sc.buf = sc.buf[:n]
+	sc.base += keep
+	sc.from = limit - keep
+}
+
+// shift adds an offset to all the spans in a match
+func (m *Match) shift(offset int) {
+	spans := make([]Span, len(m.Spans))
+	for i, span := range m.Spans {
+		spans[i] = Span{span.Begin + offset, span.End + offset}
+	}
+	m.Spans = spans
+	for _, sp := range []*Span{&m.dateSpan, &m.timeSpan} {
+		if sp.Begin != sp.End {
+			sp.Begin += offset
+			sp.End += offset
+		}
+	}
+	for _, fs := range m.Fields.all() {
+		if fs.Begin != fs.End {
+			fs.Begin += offset
+			fs.End += offset
+		}
+	}
+}
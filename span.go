@@ -2,6 +2,7 @@ package fuzzytime
 
 import (
 	"sort"
+	"unicode/utf8"
 )
 
 // Span represents the range [Begin,End), used to indicate the part
@@ -11,6 +12,39 @@ type Span struct {
 	End   int
 }
 
+// Runes converts the span from byte offsets in s to rune offsets, for
+// use with tools which count characters (eg []rune(s)).
+// Invalid UTF-8 counts as one rune per byte.
+func (sp Span) Runes(s string) Span {
+	return Span{Begin: runeOffset(s, sp.Begin), End: runeOffset(s, sp.End)}
+}
+
+// UTF16 converts the span from byte offsets in s to UTF-16 code unit
+// offsets, as used by JavaScript, Java and .NET strings. Runes outside
+// the Basic Multilingual Plane count as two units (a surrogate pair).
+func (sp Span) UTF16(s string) Span {
+	return Span{Begin: utf16Offset(s, sp.Begin), End: utf16Offset(s, sp.End)}
+}
+
+// runeOffset returns the number of runes in s before byte offset n
+func runeOffset(s string, n int) int {
+	return utf8.RuneCountInString(s[:n])
+}
+
+// utf16Offset returns the number of UTF-16 code units in s before byte
+// offset n
+func utf16Offset(s string, n int) int {
+	units := 0
+	for _, r := range s[:n] {
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	return units
+}
+
 type spanSlice []Span
 
 // implement sort.Interface
@@ -40,7 +74,9 @@ func tidySpans(in []Span) []Span {
 				break
 			}
 			// overlapping (or adjacent)
-			foo.End = tmp[i+i].End
+			if tmp[i+1].End > foo.End {
+				foo.End = tmp[i+1].End
+			}
 			i++
 		}
 		out = append(out, foo)
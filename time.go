@@ -21,6 +21,7 @@ type Time struct {
 	second   int
 	fractional int
 	tzOffset int // offset from UTC, in seconds
+	tzName   string // timezone abbreviation, if given (eg "BST")
 }
 
 // Hour returns the hour (result undefined if field unset)
@@ -51,8 +52,20 @@ func (t *Time) SetSecond(second int) { t.second = second; t.set |= secondFlag }
 // SetFractional sets the Fractional Second field (0-999)
 func (t *Time) SetFractional(fractional int) { t.fractional = fractional; t.set |= fractionalFlag }
 
-// SetTZOffset sets the timezone offset from UTC, in seconds
-func (t *Time) SetTZOffset(tzOffset int) { t.tzOffset = tzOffset; t.set |= tzFlag }
+// TZName returns the abbreviation of the timezone (eg "BST"), if the
+// time was parsed from one, or "" otherwise
+func (t *Time) TZName() string { return t.tzName }
+
+// SetTZOffset sets the timezone offset from UTC, in seconds. Any timezone
+// name is cleared.
+func (t *Time) SetTZOffset(tzOffset int) { t.tzOffset = tzOffset; t.tzName = ""; t.set |= tzFlag }
+
+// SetTZName sets the timezone offset from UTC, in seconds, along with the
+// abbreviation of the zone it came from (eg "BST")
+func (t *Time) SetTZName(tzOffset int, name string) {
+	t.SetTZOffset(tzOffset)
+	t.tzName = name
+}
 
 // HasHour returns true if the hour is set
 func (t *Time) HasHour() bool { return (t.set & hourFlag) != 0 }
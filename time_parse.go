@@ -15,7 +15,14 @@ import (
 var tzPat = `(?i)(?P<tz>Z|[A-Z]{2,5}|(([-+])(\d{2})((:?)(\d{2}))?))`
 var ampmPat = `(?i)(?:(?P<am>(am|a[.]m[.]))|(?P<pm>(pm|p[.]m[.])))`
 
+// 午前/午後 (ja), 上午/下午 (zh), 오전/오후 (ko)
+var cjkAMPMPat = `(?:(?P<am>午前|上午|오전)|(?P<pm>午後|下午|오후))`
+
 var timeCrackers = []*regexp.Regexp{
+	// "午後3:30", "오전 10：05"
+	// (before the others, which would pick up the time but miss the am/pm)
+	regexp.MustCompile(cjkAMPMPat + `[\s\p{Z}]*(?P<hour>\d{1,2})[:](?P<min>\d{2})(?:[:](?P<sec>\d{2}))?`),
+
 	// "4:48PM GMT"
 	regexp.MustCompile(`(?i)(?P<hour>\d{1,2})[:.](?P<min>\d{2})(?:[:.](?P<sec>\d{2}))?[\s\p{Z}]*` + ampmPat + `[\s\p{Z}]*` + tzPat),
 
@@ -36,6 +43,10 @@ var timeCrackers = []*regexp.Regexp{
 	// "14:21"
 	// "23:59:59.994"
 	regexp.MustCompile(`(?i)(?:\b|T)(?P<hour>\d{1,2})[:](?P<min>\d{2})(?:[:](?P<sec>\d{2})(?:[.,](?P<fractional>\d{3}))?)?(?:[^\d]|\z)`),
+
+	// "15時30分", "午後3時30分20秒", "오후 3시 30분"
+	// "午後3時" (hour only)
+	regexp.MustCompile(`(?:` + cjkAMPMPat + `[\s\p{Z}]*)?(?P<hour>\d{1,2})[\s\p{Z}]*(?P<hourmark>[時时点點시])(?:[\s\p{Z}]*(?P<min>\d{1,2})[\s\p{Z}]*[分분](?:[\s\p{Z}]*(?P<sec>\d{1,2})[\s\p{Z}]*[秒초])?)?`),
 }
 
 // ExtractTime tries to parse a time from a string.
@@ -44,6 +55,34 @@ var timeCrackers = []*regexp.Regexp{
 // An error will be returned if a time is found but cannot be correctly parsed.
 // If error is not nil time the returned time and span will both be empty
 func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
+	norm, om := normalise(s)
+	ft, span, _, err := ctx.extractTime(norm)
+	return ft, om.span(span), err
+}
+
+// extractTime does the work for ExtractTime, on a normalised string
+func (ctx *Context) extractTime(s string) (Time, Span, Fields, error) {
+	cands := ctx.timeCandidates(s, 1)
+	if len(cands) == 0 {
+		// nothing. Just return an empty time and span
+		return Time{}, Span{}, Fields{}, nil
+	}
+	return cands[0].t, cands[0].span, cands[0].fields, nil
+}
+
+// timeCandidate is a possible time found in a string
+type timeCandidate struct {
+	t      Time
+	span   Span
+	fields Fields
+}
+
+// timeCandidates returns up to max possible times in s (a normalised
+// string), in order of preference - the first match of each cracker, in
+// the order the crackers are tried.
+func (ctx *Context) timeCandidates(s string, max int) []timeCandidate {
+	var cands []timeCandidate
+crackers:
 	for _, pat := range timeCrackers {
 		names := pat.SubexpNames()
 		matchSpans := pat.FindStringSubmatchIndex(s)
@@ -54,9 +93,13 @@ func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
 		var hour, minute, second, fractional int = -1, -1, -1, -1
 		var am, pm bool = false, false
 
+		var hourMarked = false // explicit hour marker (eg "時"), so minutes are optional
+		var weakMark = false   // hour marker which might not be one (eg "点")
 		var gotTZ = false
+		var tzName string
 		var tzOffset int
 		var fail, err error
+		var fields Fields
 		for i, name := range names {
 			start, end := matchSpans[i*2], matchSpans[(i*2)+1]
 			if start == end {
@@ -67,8 +110,11 @@ func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
 				sub = strings.ToLower(s[start:end])
 			}
 
+			fieldSpan := Span{start, end}
+
 			switch name {
 			case "hour":
+				fields.Hour.Span = fieldSpan
 				hour, err = strconv.Atoi(sub)
 				if err != nil {
 					fail = err
@@ -80,6 +126,7 @@ func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
 				}
 
 			case "min":
+				fields.Minute.Span = fieldSpan
 				minute, err = strconv.Atoi(sub)
 				if err != nil {
 					fail = err
@@ -90,6 +137,7 @@ func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
 					break
 				}
 			case "sec":
+				fields.Second.Span = fieldSpan
 				second, err = strconv.Atoi(sub)
 				if err != nil {
 					fail = err
@@ -99,19 +147,26 @@ func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
 					fail = errors.New("bad seconds value")
 					break
 				}
+			case "hourmark":
+				hourMarked = true
+				weakMark = sub == "点" || sub == "點"
 			case "am":
+				fields.AMPM.Span = fieldSpan
 				am = true
 			case "pm":
+				fields.AMPM.Span = fieldSpan
 				pm = true
 			case "tz":
-				offset, err := ctx.parseTZ(sub)
+				offset, name, err := ctx.parseTZ(sub)
 				if err != nil {
 					break
 					//return Time{}, Span{}, err
 				}
-				tzOffset = offset
+				tzOffset, tzName = offset, name
 				gotTZ = true
+				fields.TZ.Span = fieldSpan
 			case "fractional":
+				fields.Fractional.Span = fieldSpan
 				fractional, err = strconv.Atoi(sub)
 				if err != nil {
 					fail = err
@@ -129,8 +184,13 @@ func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
 			break
 		}
 
+		if weakMark && !am && !pm {
+			// "点" is also "point" ("3点" = "3 points"), so need more to go on
+			hourMarked = false
+		}
+
 		// got enough to accept?
-		if hour >= 0 && minute >= 0 {
+		if hour >= 0 && (minute >= 0 || hourMarked) {
 			if pm && (hour >= 1) && (hour <= 11) {
 				hour += 12
 			}
@@ -140,7 +200,9 @@ func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
 
 			ft := Time{}
 			ft.SetHour(hour)
-			ft.SetMinute(minute)
+			if minute >= 0 {
+				ft.SetMinute(minute)
+			}
 			if second >= 0 {
 				ft.SetSecond(second)
 			}
@@ -148,24 +210,33 @@ func (ctx *Context) ExtractTime(s string) (Time, Span, error) {
 				ft.SetFractional(fractional)
 			}
 			if gotTZ {
-				ft.SetTZOffset(tzOffset)
+				ft.SetTZName(tzOffset, tzName)
 			}
 			var span = Span{matchSpans[0], matchSpans[1]}
-			return ft, span, nil
+			for _, c := range cands {
+				if c.span == span {
+					continue crackers // already got it
+				}
+			}
+			cands = append(cands, timeCandidate{ft, span, fields})
+			if len(cands) >= max {
+				break
+			}
 		}
 	}
-
-	// nothing. Just return an empty time and span
-	return Time{}, Span{}, nil
+	return cands
 }
 
-func (ctx *Context) parseTZ(s string) (int, error) {
+// parseTZ parses a timezone, returning the offset and, if it was a named
+// zone, the (uppercased) name
+func (ctx *Context) parseTZ(s string) (int, string, error) {
 	s = strings.ToUpper(s)
 	// try as an ISO 8601-style offset ("+01:30" etc)
 	offset, err := TZToOffset(s)
-	if err != nil {
-		// nope, try resolving as a named timezone via the context
-		offset, err = ctx.TZResolver(s)
+	if err == nil {
+		return offset, "", nil
 	}
-	return offset, err
+	// nope, try resolving as a named timezone via the context
+	offset, err = ctx.TZResolver(s)
+	return offset, s, err
 }
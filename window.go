@@ -0,0 +1,152 @@
+package fuzzytime
+
+import "unicode/utf8"
+
+// The searches behind ExtractAll, ExtractPairs and Scanner find the best
+// match in a string, blank it out, and go round again. That takes time
+// proportional to the length of the string times the number of matches,
+// so longer strings are searched a window at a time instead.
+const (
+	windowSize    = 256 // bytes of new text searched in each window
+	windowContext = 64  // characters of context kept before each window
+	windowMargin  = 64  // characters after each window (plus any PairDistance)
+	maxWordLen    = 32  // furthest to look for the start of a word, in bytes
+)
+
+// searchAll finds the matches in buf which begin in [from, limit),
+// searching a window at a time with extractWindow. Each window has some
+// context before it, and margin characters after it (which can run past
+// limit), and starts at the beginning of a word where possible. The
+// matches are blanked out of buf, and returned in order of position.
+// If an error occurs, the matches before it are returned along with it.
+func searchAll(buf []byte, from, limit, margin int, find func(s string) (Match, error)) ([]Match, error) {
+	s := string(buf)
+	matches := []Match{}
+	var err error
+	for from < limit && err == nil {
+		end := wordStart(s, from+windowSize, maxWordLen)
+		if end > limit {
+			end = limit
+		}
+		lo := wordStart(s, runesBack(s, from, windowContext), maxWordLen)
+		if lo > from {
+			lo = from
+		}
+		hi := wordStart(s, runesOn(s, end, margin), maxWordLen)
+		var found []Match
+		found, err = extractWindow(buf[lo:hi], from-lo, end-lo, find)
+		for i := range found {
+			found[i].shift(lo)
+		}
+		matches = append(matches, found...)
+		from = end
+	}
+	sortMatches(matches)
+	return matches, err
+}
+
+// extractWindow does the searching for a window. find is called
+// repeatedly for the best match left in buf, which is then blanked out.
+// Matches beginning in [from, limit) are returned (with offsets relative
+// to buf) and blanked out of buf too, so later windows skip them; the
+// others are left for the neighbouring windows. find reports errors along
+// with the span of the text at fault, and the first error in [from, limit)
+// is returned along with the matches before it.
+func extractWindow(buf []byte, from, limit int, find func(s string) (Match, error)) ([]Match, error) {
+	orig := string(buf)
+	work := []byte(orig)
+	found := []Match{}
+	var firstErr error
+	errAt := limit
+	for {
+		m, err := find(string(work))
+		if err == nil && m.DateTime.Empty() {
+			break
+		}
+		if blank(work, m.Spans) == 0 {
+			// don't know where the error is, so stop here
+			if firstErr == nil {
+				firstErr = err
+			}
+			break
+		}
+		begin := m.Spans[0].Begin
+		switch {
+		case begin < from || begin >= limit:
+			// context, or left for the next window
+		case err != nil:
+			if begin < errAt {
+				firstErr, errAt = err, begin
+			}
+		default:
+			// the field text should come from the original string, not
+			// the blanked-out one
+			m.Fields.setText(orig)
+			blank(buf, m.Spans)
+			found = append(found, m)
+		}
+	}
+	if firstErr != nil {
+		kept := found[:0]
+		for _, m := range found {
+			if m.Spans[0].Begin < errAt {
+				kept = append(kept, m)
+			}
+		}
+		found = kept
+	}
+	return found, firstErr
+}
+
+// blank overwrites the spans in buf with spaces (keeping the offsets the
+// same), returning the number of bytes blanked
+func blank(buf []byte, spans []Span) int {
+	n := 0
+	for _, span := range spans {
+		for i := span.Begin; i < span.End; i++ {
+			buf[i] = ' '
+			n++
+		}
+	}
+	return n
+}
+
+// wordStart returns the first offset at or after pos which begins a word
+// (ie follows whitespace), looking up to max bytes ahead. Failing that,
+// it returns the first character boundary at or after pos.
+func wordStart(s string, pos, max int) int {
+	if pos <= 0 {
+		return 0
+	}
+	if pos >= len(s) {
+		return len(s)
+	}
+	for i := pos; i < len(s) && i < pos+max; i++ {
+		switch s[i-1] {
+		case ' ', '\t', '\n', '\r', '\f', '\v':
+			return i
+		}
+	}
+	for pos < len(s) && !utf8.RuneStart(s[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// runesBack returns the offset n characters before pos
+func runesBack(s string, pos, n int) int {
+	for ; n > 0 && pos > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+// runesOn returns the offset n characters after pos
+func runesOn(s string, pos, n int) int {
+	for ; n > 0 && pos < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		pos += size
+	}
+	return pos
+}